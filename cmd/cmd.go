@@ -1,17 +1,32 @@
 package cmd
 
 import (
+	"context"
 	_ "embed"
 	"log/slog"
 	"os"
 	"os/signal"
 	"sync"
 	"syscall"
-
+	"time"
+
+	"gabe565.com/castsponsorskip/cmd/configcmd"
+	"gabe565.com/castsponsorskip/cmd/doctor"
+	"gabe565.com/castsponsorskip/cmd/importdb"
+	"gabe565.com/castsponsorskip/cmd/segments"
+	"gabe565.com/castsponsorskip/cmd/serve"
+	"gabe565.com/castsponsorskip/cmd/vote"
 	"gabe565.com/castsponsorskip/internal/config"
+	"gabe565.com/castsponsorskip/internal/config/names"
+	"gabe565.com/castsponsorskip/internal/control"
+	"gabe565.com/castsponsorskip/internal/dashboard"
 	"gabe565.com/castsponsorskip/internal/device"
+	"gabe565.com/castsponsorskip/internal/history"
+	"gabe565.com/castsponsorskip/internal/sponsorblock"
+	"gabe565.com/castsponsorskip/internal/tracing"
 	"gabe565.com/castsponsorskip/internal/youtube"
 	"gabe565.com/utils/cobrax"
+	"gabe565.com/utils/must"
 	"github.com/spf13/cobra"
 )
 
@@ -20,11 +35,11 @@ var long string
 
 func New(opts ...cobrax.Option) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "castsponsorskip",
-		Short:   "Skip sponsored YouTube segments on local Cast devices",
-		Long:    long,
-		PreRunE: preRun,
-		RunE:    run,
+		Use:               "castsponsorskip",
+		Short:             "Skip sponsored YouTube segments on local Cast devices",
+		Long:              long,
+		PersistentPreRunE: preRun,
+		RunE:              run,
 
 		ValidArgsFunction: func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
 			return nil, cobra.ShellCompDirectiveNoFileComp
@@ -35,8 +50,18 @@ func New(opts ...cobrax.Option) *cobra.Command {
 
 	config.InitLog(cmd.ErrOrStderr(), slog.LevelInfo, config.FormatAuto)
 	config.RegisterFlags(cmd)
+	cmd.Flags().Bool(names.FlagTUI, false, "Show a live dashboard of all devices and recent events instead of logs")
 	config.RegisterCompletions(cmd)
 
+	cmd.AddCommand(
+		configcmd.New(),
+		doctor.New(),
+		importdb.New(),
+		segments.New(),
+		serve.New(),
+		vote.New(),
+	)
+
 	for _, opt := range opts {
 		opt(cmd)
 	}
@@ -62,10 +87,60 @@ func run(cmd *cobra.Command, _ []string) error {
 	ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM, syscall.SIGQUIT)
 	defer cancel()
 
-	if conf.YouTubeAPIKey != "" {
-		if err := youtube.CreateService(ctx, conf.YouTubeAPIKey); err != nil {
-			return err
+	shutdownTracing, err := tracing.Setup(ctx, conf.OTLPEndpoint, cobrax.GetVersion(cmd))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Warn("Failed to flush traces.", "error", err.Error())
 		}
+	}()
+
+	go reloadOnSignal(ctx, cmd)
+
+	if err := youtube.CreateService(ctx, conf.YouTubeAPIKeys(), conf.YouTubeAPIQuota); err != nil {
+		return err
+	}
+	if quota, ok := youtube.Quota(); ok {
+		slog.Info("Using YouTube API.", "keys", quota.Keys, "daily_quota", quota.Limit)
+	}
+	youtube.Setup(conf)
+
+	var dashboardDone chan struct{}
+	if must.Must2(cmd.Flags().GetBool(names.FlagTUI)) {
+		prevLogger := slog.Default()
+		slog.SetDefault(slog.New(config.LogHandler(dashboard.NewLogHandler(slog.LevelWarn))))
+		dashboardDone = make(chan struct{})
+		go func() {
+			defer close(dashboardDone)
+			dashboard.Run(ctx, cmd.OutOrStdout(), time.Second)
+			slog.SetDefault(prevLogger)
+		}()
+	}
+
+	if conf.ControlListen != "" {
+		go func() {
+			if err := control.ListenAndServe(ctx, conf.ControlListen); err != nil {
+				slog.Error("Failed to serve control API.", "error", err.Error())
+			}
+		}()
+	}
+
+	deviceOpts := []device.Option{device.WithContext(ctx)}
+	if path, err := conf.SegmentHistoryPath(); err == nil {
+		recorder, stopHistory := startHistory(ctx, path)
+		defer stopHistory()
+		deviceOpts = append(deviceOpts, device.WithHistory(recorder))
+	} else {
+		slog.Warn("Failed to find segment history path. Segment history is disabled.", "error", err.Error())
+	}
+	if conf.ReportSkips {
+		reporter, stopReporter := startReporter(ctx, conf, sponsorblock.ReportInterval)
+		defer stopReporter()
+		deviceOpts = append(deviceOpts, device.WithReporter(reporter))
 	}
 
 	entries, err := device.BeginDiscover(ctx, conf)
@@ -78,13 +153,16 @@ func run(cmd *cobra.Command, _ []string) error {
 		select {
 		case <-ctx.Done():
 			cancel()
+			if dashboardDone != nil {
+				<-dashboardDone
+			}
 			slog.Info("Gracefully closing connections... Press Ctrl+C again to force exit.")
 			group.Wait()
 			slog.Info("Exiting.")
 			return nil
 		case entry := <-entries:
 			group.Go(func() {
-				if d := device.NewDevice(conf, entry, device.WithContext(ctx)); d != nil {
+				if d := device.NewDevice(conf, entry, deviceOpts...); d != nil {
 					_ = d.BeginTick()
 					_ = d.Close()
 				}
@@ -92,3 +170,47 @@ func run(cmd *cobra.Command, _ []string) error {
 		}
 	}
 }
+
+// startReporter reports skipped segments in the background until stop is called.
+// stop sends the remaining reports, so it must run on every return path.
+func startReporter(ctx context.Context, conf *config.Config, interval time.Duration) (*sponsorblock.Reporter, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	reporter := sponsorblock.NewReporter(conf, interval)
+	go reporter.Run(ctx)
+	return reporter, func() {
+		cancel()
+		reporter.Wait()
+	}
+}
+
+// startHistory records segment history in the background until stop is called.
+// stop writes the remaining entries, so it must run on every return path.
+func startHistory(ctx context.Context, path string) (*history.Recorder, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	recorder := history.NewRecorder(path)
+	go recorder.Run(ctx)
+	return recorder, func() {
+		cancel()
+		recorder.Wait()
+	}
+}
+
+// reloadOnSignal reloads log levels when the process receives SIGHUP.
+func reloadOnSignal(ctx context.Context, cmd *cobra.Command) {
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	defer signal.Stop(reload)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-reload:
+			if err := config.Reload(cmd); err != nil {
+				slog.Error("Failed to reload log levels. Keeping current levels.", "error", err.Error())
+				continue
+			}
+			slog.Info("Reloaded log levels.")
+		}
+	}
+}
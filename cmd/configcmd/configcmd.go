@@ -0,0 +1,23 @@
+package configcmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the config file",
+
+		// Subcommands load the config themselves so that invalid configs can be reported.
+		PersistentPreRunE: func(_ *cobra.Command, _ []string) error { return nil },
+	}
+
+	cmd.AddCommand(
+		newInit(),
+		newShow(),
+		newValidate(),
+	)
+
+	return cmd
+}
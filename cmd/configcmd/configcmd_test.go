@@ -0,0 +1,95 @@
+package configcmd
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRoot(t *testing.T, out *strings.Builder, args ...string) *cobra.Command {
+	root := &cobra.Command{Use: "castsponsorskip", SilenceErrors: true}
+	config.RegisterFlags(root)
+	root.AddCommand(New())
+	root.SetOut(out)
+	root.SetErr(out)
+	root.SetArgs(args)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	return root
+}
+
+func TestInitValidate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "castsponsorskip", "config.yaml")
+
+	var out strings.Builder
+	require.NoError(t, newRoot(t, &out, "config", "init", "--config", path).Execute())
+	assert.Contains(t, out.String(), path)
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "#categories:")
+	assert.Contains(t, string(b), "# Comma-separated list of SponsorBlock categories to skip\n")
+
+	require.ErrorIs(t, newRoot(t, &out, "config", "init", "--config", path).Execute(), ErrConfigExists)
+	require.NoError(t, newRoot(t, &out, "config", "init", "--config", path, "--force").Execute())
+
+	out.Reset()
+	require.NoError(t, newRoot(t, &out, "config", "validate", path).Execute())
+	assert.Contains(t, out.String(), "Config is valid: "+path)
+
+	require.NoError(t, os.WriteFile(path, []byte("devices: [not-an-ip]\n"), 0o600))
+	require.Error(t, newRoot(t, &out, "config", "validate", path).Execute())
+}
+
+func TestValidate_OnlyPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("skip-delay: 1s\n"), 0o600))
+
+	// Invalid envs and other config files don't affect the given file
+	t.Setenv("CSS_ACTION_TYPES", "mutte")
+	var out strings.Builder
+	require.NoError(t, newRoot(t, &out, "config", "validate", path).Execute())
+	assert.Equal(t, "Config is valid: "+path+"\n", out.String())
+
+	require.Error(t, newRoot(t, &out, "config", "validate", "--config", path).Execute())
+	require.Error(t, newRoot(t, &out, "config", "validate", filepath.Join(t.TempDir(), "missing.yaml")).Execute())
+}
+
+func TestShow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("skip-delay: 1s\nyoutube-api-key: secret\n"), 0o600))
+	t.Setenv("CSS_PAUSED_INTERVAL", "2m")
+
+	var out strings.Builder
+	require.NoError(t, newRoot(t, &out, "config", "show", "--config", path, "--categories", "sponsor,intro").Execute())
+
+	got := out.String()
+	assert.Contains(t, got, "skip-delay: 1s # file "+path+"\n")
+	assert.Contains(t, got, "paused-interval: 2m0s # env\n")
+	assert.Contains(t, got, "categories: # flag\n")
+	assert.Contains(t, got, "playing-interval: 500ms # default\n")
+	assert.Contains(t, got, "youtube-api-key: "+redacted+" # file "+path+"\n")
+	assert.NotContains(t, got, "secret")
+}
+
+func TestShowValidate_NoLogSinks(t *testing.T) {
+	// Nothing listens on the address, so opening the sink would fail
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	sink := "syslog+tcp://" + listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	var out strings.Builder
+	require.NoError(t, newRoot(t, &out, "config", "show", "--log-sinks", sink).Execute())
+	assert.Contains(t, out.String(), sink)
+
+	out.Reset()
+	require.NoError(t, newRoot(t, &out, "config", "validate", "--log-sinks", sink).Execute())
+	assert.Contains(t, out.String(), "valid")
+}
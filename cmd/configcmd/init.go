@@ -0,0 +1,94 @@
+package configcmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"gabe565.com/castsponsorskip/internal/config/names"
+	"gabe565.com/utils/must"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const FlagForce = "force"
+
+var ErrConfigExists = errors.New("config file already exists")
+
+func newInit() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Write a commented default config file",
+		Long: `Write a commented default config file.
+
+The file is written to the path set by --config, or to the first path that CastSponsorSkip searches.
+Every key is commented out, so the defaults continue to apply until a key is uncommented.`,
+		Args: cobra.NoArgs,
+		RunE: runInit,
+
+		ValidArgsFunction: cobra.NoFileCompletions,
+		SilenceUsage:      true,
+	}
+
+	cmd.Flags().BoolP(FlagForce, "f", false, "Overwrite an existing config file")
+
+	return cmd
+}
+
+func runInit(cmd *cobra.Command, _ []string) error {
+	path := must.Must2(cmd.Flags().GetString(names.FlagConfig))
+	if path == "" {
+		var err error
+		if path, err = config.UserConfigPath(); err != nil {
+			return err
+		}
+	}
+
+	if !must.Must2(cmd.Flags().GetBool(FlagForce)) {
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("%w: %s", ErrConfigExists, path)
+		}
+	}
+
+	contents, err := defaultConfig(cmd.Root().PersistentFlags())
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(cmd.OutOrStdout(), "Wrote default config to", path)
+	return err
+}
+
+func defaultConfig(fs *pflag.FlagSet) (string, error) {
+	var buf strings.Builder
+	buf.WriteString("# CastSponsorSkip config\n")
+	buf.WriteString("# Uncomment a key to change its default value.\n")
+
+	for _, field := range config.New().Fields() {
+		buf.WriteString("\n")
+		if flag := fs.Lookup(field.Key); flag != nil {
+			buf.WriteString("# " + flag.Usage + "\n")
+		}
+
+		s, err := field.Marshal()
+		if err != nil {
+			return "", err
+		}
+		for line := range strings.Lines(s) {
+			buf.WriteString("#" + line)
+		}
+	}
+
+	return buf.String(), nil
+}
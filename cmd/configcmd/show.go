@@ -0,0 +1,74 @@
+package configcmd
+
+import (
+	"io"
+	"slices"
+	"strings"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"gabe565.com/utils/must"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagShowSecrets = "show-secrets"
+
+	redacted = "<redacted>"
+)
+
+func newShow() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "show",
+		Short: "Print the effective config and where each value came from",
+		Long: `Print the effective config and where each value came from.
+
+Each key is followed by the layer that supplied it.
+Layers are applied in order: default, file, sponsorblockcast env, env, flag.`,
+		Args: cobra.NoArgs,
+		RunE: runShow,
+
+		ValidArgsFunction: cobra.NoFileCompletions,
+		SilenceUsage:      true,
+	}
+
+	cmd.Flags().Bool(FlagShowSecrets, false, "Print secrets like API keys instead of redacting them")
+
+	return cmd
+}
+
+func runShow(cmd *cobra.Command, _ []string) error {
+	conf, err := config.Resolve(cmd)
+	if err != nil {
+		return err
+	}
+
+	showSecrets := must.Must2(cmd.Flags().GetBool(FlagShowSecrets))
+	return writeSources(cmd.OutOrStdout(), conf, showSecrets)
+}
+
+func writeSources(w io.Writer, conf *config.Config, showSecrets bool) error {
+	secretKeys := config.SecretKeys()
+
+	var buf strings.Builder
+	for _, field := range conf.Fields() {
+		if !showSecrets && slices.Contains(secretKeys, field.Key) && field.Value != "" {
+			field.Value = redacted
+		}
+
+		s, err := field.Marshal()
+		if err != nil {
+			return err
+		}
+
+		source := conf.Sources[field.Key]
+		if source == "" {
+			source = config.SourceDefault
+		}
+
+		first, rest, _ := strings.Cut(s, "\n")
+		buf.WriteString(first + " # " + string(source) + "\n" + rest)
+	}
+
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
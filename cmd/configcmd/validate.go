@@ -0,0 +1,58 @@
+package configcmd
+
+import (
+	"fmt"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func newValidate() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate [path]",
+		Short: "Check a config file without starting CastSponsorSkip",
+		Long: `Check a config file without starting CastSponsorSkip.
+
+If a path is given, only that file is checked. Other config files, envs and flags are ignored.
+Otherwise, the effective config is checked: the system config, its conf.d drop-ins, the user config, envs and flags.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runValidate,
+
+		ValidArgsFunction: func(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return []string{"yaml", "yml"}, cobra.ShellCompDirectiveFilterFileExt
+		},
+		SilenceUsage: true,
+	}
+
+	return cmd
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		if _, err := config.LoadFile(args[0]); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintln(cmd.OutOrStdout(), "Config is valid:", args[0])
+		return err
+	}
+
+	conf, err := config.Resolve(cmd)
+	if err != nil {
+		return err
+	}
+
+	if len(conf.ConfigFiles) == 0 {
+		_, err = fmt.Fprintln(cmd.OutOrStdout(), "No config file found. The effective config is valid.")
+		return err
+	}
+
+	for _, path := range conf.ConfigFiles {
+		if _, err := fmt.Fprintln(cmd.OutOrStdout(), "Config is valid:", path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,377 @@
+package doctor
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"gabe565.com/castsponsorskip/internal/config/names"
+	"gabe565.com/castsponsorskip/internal/sponsorblock"
+	"gabe565.com/castsponsorskip/internal/youtube"
+	castdns "github.com/vishen/go-chromecast/dns"
+)
+
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+)
+
+type Result struct {
+	Status  Status
+	Name    string
+	Message string
+	Hint    string
+}
+
+const (
+	knownVideoID     = "dQw4w9WgXcQ"
+	knownVideoArtist = "Rick Astley"
+	knownVideoTitle  = "Rick Astley - Never Gonna Give You Up (Official Music Video)"
+
+	hintHostNetwork = "If running in Docker, use --network=host. In Kubernetes, enable hostNetwork on the pod."
+)
+
+func checkNetworkInterfaces(_ context.Context, conf *config.Config, _ time.Duration) []Result {
+	if len(conf.NetworkInterfaces) == 0 {
+		interfaces, err := net.Interfaces()
+		if err != nil {
+			return []Result{{
+				Status:  StatusFail,
+				Name:    "Network interfaces",
+				Message: err.Error(),
+			}}
+		}
+
+		var usable []string
+		for _, iface := range interfaces {
+			if iface.Flags&net.FlagUp != 0 && iface.Flags&net.FlagMulticast != 0 && iface.Flags&net.FlagLoopback == 0 {
+				usable = append(usable, iface.Name)
+			}
+		}
+
+		if len(usable) == 0 {
+			return []Result{{
+				Status:  StatusFail,
+				Name:    "Network interfaces",
+				Message: "no multicast-capable interfaces are up",
+				Hint:    hintHostNetwork,
+			}}
+		}
+		return []Result{{
+			Status:  StatusPass,
+			Name:    "Network interfaces",
+			Message: "using all interfaces (" + strings.Join(usable, ", ") + ")",
+		}}
+	}
+
+	results := make([]Result, 0, len(conf.NetworkInterfaces))
+	for _, iface := range conf.NetworkInterfaces {
+		result := Result{Name: "Network interface " + iface.Name}
+		addrs, _ := iface.Addrs()
+		addrStrs := make([]string, 0, len(addrs))
+		for _, addr := range addrs {
+			addrStrs = append(addrStrs, addr.String())
+		}
+
+		switch {
+		case iface.Flags&net.FlagUp == 0:
+			result.Status = StatusFail
+			result.Message = "interface is down"
+			result.Hint = "Bring the interface up, or choose another with --" + names.FlagNetworkInterface + "."
+		case iface.Flags&net.FlagMulticast == 0:
+			result.Status = StatusFail
+			result.Message = "interface does not support multicast"
+			result.Hint = "Device discovery uses multicast DNS. Choose another interface with --" +
+				names.FlagNetworkInterface + "."
+		case len(addrs) == 0:
+			result.Status = StatusWarn
+			result.Message = "interface has no addresses"
+			result.Hint = "Check that the interface is connected to the same network as your Cast devices."
+		default:
+			result.Status = StatusPass
+			result.Message = "up, multicast (" + strings.Join(addrStrs, ", ") + ")"
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func checkDiscovery(ctx context.Context, conf *config.Config, timeout time.Duration) []Result {
+	const name = "Device discovery"
+	if len(conf.DeviceAddrs) != 0 {
+		return []Result{{
+			Status:  StatusWarn,
+			Name:    name,
+			Message: "disabled because devices are configured with --" + names.FlagDevices,
+			Hint:    "Remove --" + names.FlagDevices + " to discover devices automatically.",
+		}}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	interfaces := conf.NetworkInterfaces
+	if len(interfaces) == 0 {
+		interfaces = []*net.Interface{nil}
+	}
+
+	var (
+		entries = make(map[string]castdns.CastEntry)
+		mu      sync.Mutex
+		group   sync.WaitGroup
+		results []Result
+	)
+	for _, iface := range interfaces {
+		ch, err := castdns.DiscoverCastDNSEntries(ctx, iface)
+		if err != nil {
+			results = append(results, Result{
+				Status:  StatusFail,
+				Name:    name,
+				Message: err.Error(),
+				Hint:    hintHostNetwork,
+			})
+			continue
+		}
+
+		group.Go(func() {
+			for entry := range ch {
+				mu.Lock()
+				entries[entry.UUID] = entry
+				mu.Unlock()
+			}
+		})
+	}
+	group.Wait()
+
+	if len(entries) == 0 {
+		return append(results, Result{
+			Status:  StatusFail,
+			Name:    name,
+			Message: "no devices found within " + timeout.String(),
+			Hint: hintHostNetwork + " Also check that the correct --" + names.FlagNetworkInterface +
+				" is selected and that UDP port 5353 is not blocked by a firewall.",
+		})
+	}
+
+	deviceNames := make([]string, 0, len(entries))
+	var ignored int
+	for _, entry := range entries {
+		if ok, _ := conf.AllowDevice(entry); !ok {
+			ignored++
+			continue
+		}
+		deviceNames = append(deviceNames, entry.DeviceName)
+	}
+	slices.Sort(deviceNames)
+
+	result := Result{
+		Status:  StatusPass,
+		Name:    name,
+		Message: "found " + strconv.Itoa(len(entries)) + " devices",
+	}
+	if len(deviceNames) != 0 {
+		result.Message += " (" + strings.Join(deviceNames, ", ") + ")"
+	}
+	if ignored != 0 {
+		result.Message += ", " + strconv.Itoa(ignored) + " ignored by device filters"
+		if len(deviceNames) == 0 {
+			result.Status = StatusWarn
+			result.Hint = "Check --" + names.FlagIncludeDevices + " and --" + names.FlagExcludeDevices + "."
+		}
+	}
+	return append(results, result)
+}
+
+func checkDevices(ctx context.Context, conf *config.Config, timeout time.Duration) []Result {
+	results := make([]Result, 0, len(conf.DeviceAddrs))
+	for _, entry := range conf.DeviceAddrs {
+		addr := net.JoinHostPort(entry.GetAddr(), strconv.Itoa(entry.GetPort()))
+		result := Result{Name: "Device " + addr}
+
+		dialer := &tls.Dialer{
+			NetDialer: &net.Dialer{Timeout: timeout},
+			Config: &tls.Config{
+				InsecureSkipVerify: true, //nolint:gosec
+			},
+		}
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err == nil {
+			_ = conn.Close()
+			result.Status = StatusPass
+			result.Message = "accepted a TLS connection"
+		} else {
+			result.Status = StatusFail
+			result.Message = err.Error()
+			result.Hint = "Check that the device is powered on, reachable from this host, and listening on port " +
+				strconv.Itoa(entry.GetPort()) + "."
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func checkSponsorBlock(ctx context.Context, conf *config.Config, timeout time.Duration) []Result {
+	const name = "SponsorBlock API"
+	if !conf.SkipSponsors {
+		return []Result{{
+			Status:  StatusWarn,
+			Name:    name,
+			Message: "sponsor skipping is disabled",
+			Hint:    "Set --" + names.FlagSkipSponsors + "=true to skip SponsorBlock segments.",
+		}}
+	}
+
+	var results []Result
+	if conf.SponsorBlockLookup == config.LookupLocal {
+		results = append(results, checkSponsorBlockDB(conf))
+		if !conf.SponsorBlockDBFallback {
+			return results
+		}
+
+		// Check the server used for fallback lookups
+		fallback := *conf
+		fallback.SponsorBlockLookup = config.LookupHash
+		conf = &fallback
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if _, err := sponsorblock.QuerySegments(ctx, conf, knownVideoID); err != nil {
+		return append(results, Result{
+			Status:  StatusFail,
+			Name:    name,
+			Message: err.Error(),
+			Hint:    "Check that this host has internet access and can reach " + conf.SponsorBlockURL + ".",
+		})
+	}
+
+	return append(results, Result{
+		Status:  StatusPass,
+		Name:    name,
+		Message: "server responded",
+	})
+}
+
+func checkSponsorBlockDB(conf *config.Config) Result {
+	result := Result{Name: "SponsorBlock database"}
+	path, err := conf.SponsorBlockDBPath()
+	if err != nil {
+		result.Status = StatusFail
+		result.Message = err.Error()
+		return result
+	}
+
+	db, err := sponsorblock.OpenDB(path)
+	if err != nil {
+		result.Status = StatusFail
+		result.Message = err.Error()
+		result.Hint = "Download sponsorTimes.csv from https://sponsor.ajay.app/database and run castsponsorskip import-db."
+		return result
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	result.Status = StatusPass
+	result.Message = fmt.Sprintf("%d videos in %s", db.Len(), path)
+	if stat, err := os.Stat(path); err == nil {
+		if age := time.Since(stat.ModTime()); age > 30*24*time.Hour {
+			result.Status = StatusWarn
+			result.Message += fmt.Sprintf(", imported %d days ago", int(age.Hours()/24))
+			result.Hint = "Import a newer database dump to get segments for recent videos."
+		}
+	}
+	return result
+}
+
+func checkYouTube(ctx context.Context, conf *config.Config, timeout time.Duration) []Result {
+	const name = "YouTube API key"
+	keys := conf.YouTubeAPIKeys()
+	if len(keys) == 0 {
+		return []Result{{
+			Status:  StatusWarn,
+			Name:    name,
+			Message: "not configured",
+			Hint:    "A key is only required for devices that do not report video IDs, unless another video ID backend is configured.",
+		}}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	results := make([]Result, 0, len(keys))
+	for i, key := range keys {
+		result := Result{Name: name}
+		if len(keys) > 1 {
+			result.Name += " " + strconv.Itoa(i+1)
+		}
+
+		err := youtube.CreateService(ctx, []string{key}, conf.YouTubeAPIQuota)
+		if err == nil {
+			err = youtube.Ping(ctx, knownVideoID)
+		}
+		switch {
+		case errors.Is(err, youtube.ErrQuotaExhausted):
+			result.Status = StatusWarn
+			result.Message = "key is valid but out of quota"
+			result.Hint = "Quotas reset at midnight Pacific time. Add more keys to rotate through them."
+		case err != nil:
+			result.Status = StatusFail
+			result.Message = err.Error()
+			result.Hint = "Check that the key is valid and that the YouTube Data API v3 is enabled for its project."
+		default:
+			result.Status = StatusPass
+			result.Message = "key is valid"
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func checkVideoIDBackends(ctx context.Context, conf *config.Config, timeout time.Duration) []Result {
+	results := make([]Result, 0, len(conf.VideoIDBackendSpecs))
+	for _, spec := range conf.VideoIDBackendSpecs {
+		var backend youtube.Backend
+		switch spec.Type {
+		case config.BackendInvidious:
+			backend = youtube.NewInvidious(spec.URL)
+		case config.BackendPiped:
+			backend = youtube.NewPiped(spec.URL)
+		default:
+			// The Data API is covered by checkYouTube
+			continue
+		}
+
+		result := Result{Name: "Video ID backend " + backend.Name()}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		id, err := backend.QueryVideoID(ctx, youtube.Query{Artist: knownVideoArtist, Title: knownVideoTitle})
+		cancel()
+		switch {
+		case err != nil:
+			result.Status = StatusFail
+			result.Message = err.Error()
+			result.Hint = "Check that the instance is online and that its API is enabled. Public instances often rate limit searches."
+		case id != knownVideoID:
+			result.Status = StatusWarn
+			result.Message = "search returned unexpected video " + id
+		default:
+			result.Status = StatusPass
+			result.Message = "search returned the expected video"
+		}
+		results = append(results, result)
+	}
+	return results
+}
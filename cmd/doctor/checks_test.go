@@ -0,0 +1,117 @@
+package doctor
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"gabe565.com/castsponsorskip/internal/sponsorblock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	castdns "github.com/vishen/go-chromecast/dns"
+)
+
+func TestCheckNetworkInterfaces(t *testing.T) {
+	conf := config.New()
+	conf.NetworkInterfaces = []*net.Interface{
+		{Name: "down0"},
+		{Name: "nomulticast0", Flags: net.FlagUp},
+	}
+
+	results := checkNetworkInterfaces(t.Context(), conf, time.Second)
+	require.Len(t, results, 2)
+	assert.Equal(t, StatusFail, results[0].Status)
+	assert.Contains(t, results[0].Message, "down")
+	assert.Equal(t, StatusFail, results[1].Status)
+	assert.Contains(t, results[1].Message, "multicast")
+}
+
+func TestCheckDevices(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	t.Cleanup(server.Close)
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	require.NoError(t, err)
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	closedPort := closed.Addr().(*net.TCPAddr).Port
+	require.NoError(t, closed.Close())
+
+	conf := config.New()
+	conf.DeviceAddrs = []castdns.CastEntry{
+		{AddrV4: net.ParseIP(u.Hostname()), Port: port},
+		{AddrV4: net.IPv4(127, 0, 0, 1), Port: closedPort},
+	}
+
+	results := checkDevices(t.Context(), conf, time.Second)
+	require.Len(t, results, 2)
+	assert.Equal(t, StatusPass, results[0].Status)
+	assert.Equal(t, StatusFail, results[1].Status)
+	assert.NotEmpty(t, results[1].Hint)
+}
+
+func TestCheckYouTube_NoKey(t *testing.T) {
+	results := checkYouTube(t.Context(), config.New(), time.Second)
+	require.Len(t, results, 1)
+	assert.Equal(t, StatusWarn, results[0].Status)
+}
+
+func TestCheckVideoIDBackends(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/search" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"type":"video","videoId":"` + knownVideoID + `","title":"` + knownVideoTitle + `","author":"` + knownVideoArtist + `"}]`))
+	}))
+	t.Cleanup(server.Close)
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	conf := config.New()
+	conf.VideoIDBackendSpecs = []config.VideoIDBackend{
+		{Type: config.BackendYouTube},
+		{Type: config.BackendInvidious, URL: u},
+		{Type: config.BackendPiped, URL: u},
+	}
+
+	results := checkVideoIDBackends(t.Context(), conf, time.Second)
+	require.Len(t, results, 2)
+	assert.Equal(t, StatusPass, results[0].Status)
+	assert.Equal(t, StatusFail, results[1].Status)
+	assert.NotEmpty(t, results[1].Hint)
+}
+
+func TestCheckSponsorBlock_Local(t *testing.T) {
+	conf := config.New()
+	conf.SponsorBlockLookup = config.LookupLocal
+	conf.SponsorBlockDBFile = filepath.Join(t.TempDir(), "sponsorblock.db")
+
+	results := checkSponsorBlock(t.Context(), conf, time.Second)
+	require.Len(t, results, 1)
+	assert.Equal(t, StatusFail, results[0].Status)
+	assert.Contains(t, results[0].Hint, "import-db")
+
+	_, err := sponsorblock.Import(
+		strings.NewReader("videoID,startTime,endTime,votes,UUID,category\n"+knownVideoID+",1,2,0,a,sponsor\n"),
+		conf.SponsorBlockDBFile,
+		sponsorblock.ImportOptions{},
+	)
+	require.NoError(t, err)
+
+	results = checkSponsorBlock(t.Context(), conf, time.Second)
+	require.Len(t, results, 1)
+	assert.Equal(t, StatusPass, results[0].Status)
+	assert.Contains(t, results[0].Message, "1 videos")
+}
@@ -0,0 +1,85 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"gabe565.com/utils/must"
+	"github.com/spf13/cobra"
+)
+
+const FlagTimeout = "timeout"
+
+var ErrChecksFailed = errors.New("checks failed")
+
+func New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Diagnose common network and configuration problems",
+		Long: `Diagnose common network and configuration problems.
+
+Loads the effective config, then checks the selected network interfaces, device discovery,
+configured devices, the SponsorBlock API, and the YouTube API key.`,
+		Args: cobra.NoArgs,
+		RunE: run,
+
+		ValidArgsFunction: cobra.NoFileCompletions,
+		SilenceUsage:      true,
+	}
+
+	cmd.Flags().Duration(FlagTimeout, 10*time.Second, "Time to wait for each network check")
+
+	return cmd
+}
+
+type checkFunc func(ctx context.Context, conf *config.Config, timeout time.Duration) []Result
+
+func run(cmd *cobra.Command, _ []string) error {
+	conf := config.FromContext(cmd.Context())
+	timeout := must.Must2(cmd.Flags().GetDuration(FlagTimeout))
+
+	ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	checks := []checkFunc{
+		checkNetworkInterfaces,
+		checkDiscovery,
+		checkDevices,
+		checkSponsorBlock,
+		checkYouTube,
+		checkVideoIDBackends,
+	}
+
+	var failed int
+	for _, check := range checks {
+		for _, result := range check(ctx, conf, timeout) {
+			printResult(cmd.OutOrStdout(), result)
+			if result.Status == StatusFail {
+				failed++
+			}
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	if failed != 0 {
+		return fmt.Errorf("%d %w", failed, ErrChecksFailed)
+	}
+	return nil
+}
+
+func printResult(w io.Writer, result Result) {
+	_, _ = fmt.Fprintf(w, "%-4s  %s: %s\n", strings.ToUpper(string(result.Status)), result.Name, result.Message)
+	if result.Hint != "" {
+		_, _ = fmt.Fprintf(w, "      hint: %s\n", result.Hint)
+	}
+}
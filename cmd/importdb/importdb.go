@@ -0,0 +1,78 @@
+package importdb
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"gabe565.com/castsponsorskip/internal/config/names"
+	"gabe565.com/castsponsorskip/internal/sponsorblock"
+	"gabe565.com/utils/must"
+	"github.com/spf13/cobra"
+)
+
+const FlagMinVotes = "min-votes"
+
+func New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import-db sponsorTimes.csv",
+		Short: "Import a SponsorBlock database dump for offline lookups",
+		Long: `Import a SponsorBlock database dump for offline lookups.
+
+Download sponsorTimes.csv from https://sponsor.ajay.app/database, or pass - to read it from stdin.
+Segments the public API would hide are skipped: hidden and shadow hidden segments, and segments below --` + FlagMinVotes + `.
+The index is written to --` + names.FlagSponsorBlockDBFile + `.
+Rows are sorted in temporary files in the same directory, which needs free space for roughly the size of the dump.
+Set --` + names.FlagSponsorBlockLookup + `=` + config.LookupLocal + ` to use it.
+A running instance picks up a new import automatically.`,
+		Args: cobra.ExactArgs(1),
+		RunE: run,
+
+		ValidArgsFunction: func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+			return []string{"csv"}, cobra.ShellCompDirectiveFilterFileExt
+		},
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().Int(FlagMinVotes, sponsorblock.DefaultMinVotes, "Lowest vote count a segment needs to be imported")
+
+	return cmd
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	conf := config.FromContext(cmd.Context())
+
+	path, err := conf.SponsorBlockDBPath()
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = cmd.InOrStdin()
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		r = f
+	}
+
+	start := time.Now()
+	stats, err := sponsorblock.Import(r, path, sponsorblock.ImportOptions{
+		MinVotes: must.Must2(cmd.Flags().GetInt(FlagMinVotes)),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(cmd.OutOrStdout(),
+		"Imported %d segments for %d videos to %s in %s.\nSkipped %d hidden and %d invalid rows out of %d.\n",
+		stats.Segments, stats.Videos, path, time.Since(start).Round(time.Millisecond),
+		stats.Hidden, stats.Invalid, stats.Rows,
+	)
+	return err
+}
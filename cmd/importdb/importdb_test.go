@@ -0,0 +1,40 @@
+package importdb
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"gabe565.com/castsponsorskip/internal/sponsorblock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportDB(t *testing.T) {
+	conf := config.New()
+	conf.SponsorBlockDBFile = filepath.Join(t.TempDir(), "sponsorblock.db")
+
+	cmd := New()
+	cmd.SetContext(config.NewContext(t.Context(), conf))
+	cmd.SetArgs([]string{"-", "--min-votes=0"})
+	cmd.SetIn(strings.NewReader("videoID,startTime,endTime,votes,UUID,category\n" +
+		"dQw4w9WgXcQ,1,2,0,a,sponsor\n" +
+		"dQw4w9WgXcQ,3,4,-1,b,sponsor\n"))
+	var out strings.Builder
+	cmd.SetOut(&out)
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, out.String(), "Imported 1 segments for 1 videos")
+	assert.Contains(t, out.String(), "Skipped 1 hidden and 0 invalid rows out of 2.")
+
+	db, err := sponsorblock.OpenDB(conf.SponsorBlockDBFile)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	segments, found, err := db.Lookup("dQw4w9WgXcQ")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Len(t, segments, 1)
+	assert.Equal(t, "a", segments[0].UUID)
+}
@@ -0,0 +1,139 @@
+package segments
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"gabe565.com/castsponsorskip/internal/device"
+	"gabe565.com/castsponsorskip/internal/sponsorblock"
+	"gabe565.com/castsponsorskip/internal/youtube"
+	"gabe565.com/utils/must"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagOutput = "output"
+	FlagAudio  = "audio"
+
+	OutputTable = "table"
+	OutputJSON  = "json"
+)
+
+var ErrInvalidOutput = errors.New("invalid output format")
+
+func New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "segments video-id-or-url",
+		Short: "Show SponsorBlock segments for a video",
+		Long: `Show SponsorBlock segments for a video.
+
+Segments are queried with the configured categories and action types.
+Use --categories and --action-types to override them.
+The action column shows what CastSponsorSkip would do when playback reaches each segment.`,
+		Args: cobra.ExactArgs(1),
+		RunE: run,
+
+		ValidArgsFunction: cobra.NoFileCompletions,
+		SilenceUsage:      true,
+	}
+
+	cmd.Flags().StringP(FlagOutput, "o", OutputTable, "Output format (one of: "+OutputTable+", "+OutputJSON+")")
+	cmd.Flags().Bool(FlagAudio, false, "Show what an audio-only device would do")
+
+	must.Must(cmd.RegisterFlagCompletionFunc(
+		FlagOutput,
+		func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+			return []string{OutputTable, OutputJSON}, cobra.ShellCompDirectiveNoFileComp
+		},
+	))
+
+	return cmd
+}
+
+type Segment struct {
+	sponsorblock.Segment
+	Plan device.SegmentPlan `json:"plan"`
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	conf := config.FromContext(cmd.Context())
+	if must.Must2(cmd.Flags().GetBool(FlagAudio)) {
+		conf = conf.ForAudioDevice()
+	}
+
+	output := must.Must2(cmd.Flags().GetString(FlagOutput))
+	if output != OutputTable && output != OutputJSON {
+		return fmt.Errorf("%w: %q", ErrInvalidOutput, output)
+	}
+
+	id, err := youtube.ParseVideoID(args[0])
+	if err != nil {
+		return err
+	}
+
+	segments, err := sponsorblock.QuerySegments(cmd.Context(), conf, id)
+	if err != nil {
+		return err
+	}
+
+	result := make([]Segment, 0, len(segments))
+	for _, segment := range segments {
+		result = append(result, Segment{
+			Segment: segment,
+			Plan:    device.PlanSegment(conf, segment),
+		})
+	}
+
+	if output == OutputJSON {
+		encoder := json.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	if len(result) == 0 {
+		_, err := fmt.Fprintln(cmd.OutOrStdout(), "No segments found for video.")
+		return err
+	}
+	return renderTable(cmd.OutOrStdout(), result)
+}
+
+func renderTable(w io.Writer, segments []Segment) error {
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Start", "End", "Duration", "Category", "Action Type", "Votes", "Locked", "UUID", "Action"})
+	for _, segment := range segments {
+		action := segment.Plan.Action
+		if segment.Plan.Reason != "" {
+			action += " (" + segment.Plan.Reason + ")"
+		}
+
+		locked := "no"
+		if segment.Locked != 0 {
+			locked = "yes"
+		}
+
+		t.AppendRow(table.Row{
+			formatSeconds(segment.Segment.Segment[0]),
+			formatSeconds(segment.Segment.Segment[1]),
+			formatSeconds(segment.Segment.Segment[1] - segment.Segment.Segment[0]),
+			segment.Category,
+			segment.ActionType,
+			strconv.Itoa(segment.Votes),
+			locked,
+			segment.UUID,
+			action,
+		})
+	}
+
+	_, err := io.WriteString(w, t.Render()+"\n")
+	return err
+}
+
+func formatSeconds(seconds float32) string {
+	return time.Duration(float64(seconds) * float64(time.Second)).Round(time.Millisecond).String()
+}
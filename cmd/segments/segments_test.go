@@ -0,0 +1,49 @@
+package segments
+
+import (
+	"strings"
+	"testing"
+
+	"gabe565.com/castsponsorskip/internal/device"
+	"gabe565.com/castsponsorskip/internal/sponsorblock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderTable(t *testing.T) {
+	segments := []Segment{
+		{
+			Segment: sponsorblock.Segment{
+				Segment:    [2]float32{53.433, 57.705},
+				UUID:       "e992e1c6dcebe5f21fc5dc68cfec12bc",
+				Category:   "sponsor",
+				ActionType: sponsorblock.ActionTypeSkip,
+				Locked:     1,
+				Votes:      3,
+			},
+			Plan: device.SegmentPlan{Action: sponsorblock.ActionTypeSkip},
+		},
+		{
+			Segment: sponsorblock.Segment{
+				Segment:    [2]float32{0, 0},
+				UUID:       "6c1c415479595a922bb1c67e4091bd80",
+				Category:   "exclusive_access",
+				ActionType: "full",
+			},
+			Plan: device.SegmentPlan{Action: device.ActionIgnore, Reason: "unsupported action type"},
+		},
+	}
+
+	var buf strings.Builder
+	require.NoError(t, renderTable(&buf, segments))
+	out := buf.String()
+	assert.Contains(t, out, "53.433s")
+	assert.Contains(t, out, "4.272s")
+	assert.Contains(t, out, "e992e1c6dcebe5f21fc5dc68cfec12bc")
+	assert.Contains(t, out, "ignore (unsupported action type)")
+}
+
+func TestFormatSeconds(t *testing.T) {
+	assert.Equal(t, "6m28.815s", formatSeconds(388.815))
+	assert.Equal(t, "0s", formatSeconds(0))
+}
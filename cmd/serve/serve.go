@@ -0,0 +1,60 @@
+package serve
+
+import (
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"gabe565.com/castsponsorskip/internal/config/names"
+	"gabe565.com/castsponsorskip/internal/sponsorblock"
+	"gabe565.com/castsponsorskip/internal/util"
+	"gabe565.com/utils/must"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagListen   = "listen"
+	FlagCacheTTL = "cache-ttl"
+)
+
+func New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve a SponsorBlock-compatible mirror for other instances",
+		Long: `Serve a SponsorBlock-compatible mirror for other instances.
+
+The mirror answers /api/skipSegments/{prefix} and /api/skipSegments?videoID= like the SponsorBlock API.
+Segments are read from the database imported with import-db when --` + names.FlagSponsorBlockLookup + `=` + config.LookupLocal + `.
+Other requests are sent to --` + names.FlagSponsorBlockURL + ` and cached for --` + FlagCacheTTL + `.
+Point other instances at the mirror with --` + names.FlagSponsorBlockURL + ` to share one cache.`,
+		Args: cobra.NoArgs,
+		RunE: run,
+
+		ValidArgsFunction: cobra.NoFileCompletions,
+		SilenceUsage:      true,
+	}
+
+	cmd.Flags().StringP(FlagListen, "l", ":8080", "Address to listen on")
+	cmd.Flags().Duration(FlagCacheTTL, 30*time.Minute, "How long to cache upstream responses")
+
+	return cmd
+}
+
+func run(cmd *cobra.Command, _ []string) error {
+	conf := config.FromContext(cmd.Context())
+	addr := must.Must2(cmd.Flags().GetString(FlagListen))
+	ttl := must.Must2(cmd.Flags().GetDuration(FlagCacheTTL))
+
+	ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	slog.Info("Serving SponsorBlock mirror.", "address", addr, "upstream", conf.SponsorBlockURL)
+	if err := util.ListenAndServe(ctx, addr, sponsorblock.NewMirror(conf, ttl).Handler()); err != nil {
+		return err
+	}
+	slog.Info("Stopped SponsorBlock mirror.")
+	return nil
+}
@@ -0,0 +1,155 @@
+package vote
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"gabe565.com/castsponsorskip/internal/config/names"
+	"gabe565.com/castsponsorskip/internal/history"
+	"gabe565.com/castsponsorskip/internal/sponsorblock"
+	"gabe565.com/utils/must"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagUp      = "up"
+	FlagDown    = "down"
+	FlagUndo    = "undo"
+	FlagHistory = "history"
+)
+
+var (
+	ErrNoVoteType   = errors.New("one of --" + FlagUp + ", --" + FlagDown + " or --" + FlagUndo + " is required")
+	ErrNoSegments   = errors.New("no segments given")
+	ErrHistoryIndex = errors.New("no segment in history at index")
+)
+
+func New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "vote [uuid-or-index...]",
+		Short: "Vote on SponsorBlock segments",
+		Long: `Vote on SponsorBlock segments.
+
+Segments are given by UUID, or by their index in the segment history.
+Run with --` + FlagHistory + ` to list recently skipped and muted segments, where 1 is the most recent.
+Votes are sent with --` + names.FlagSponsorBlockUserID + `.`,
+		Example: `  castsponsorskip vote --history
+  castsponsorskip vote --down 1`,
+		RunE: run,
+
+		ValidArgsFunction: cobra.NoFileCompletions,
+		SilenceUsage:      true,
+	}
+
+	cmd.Flags().Bool(FlagUp, false, "Upvote the segments")
+	cmd.Flags().Bool(FlagDown, false, "Downvote the segments")
+	cmd.Flags().Bool(FlagUndo, false, "Undo previous votes on the segments")
+	cmd.Flags().Bool(FlagHistory, false, "List recently skipped and muted segments")
+	cmd.MarkFlagsMutuallyExclusive(FlagUp, FlagDown, FlagUndo, FlagHistory)
+
+	return cmd
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	conf := config.FromContext(cmd.Context())
+
+	path, err := conf.SegmentHistoryPath()
+	if err != nil {
+		return err
+	}
+	entries, err := history.Load(path)
+	if err != nil {
+		return err
+	}
+
+	if must.Must2(cmd.Flags().GetBool(FlagHistory)) {
+		return renderHistory(cmd.OutOrStdout(), entries)
+	}
+
+	var voteType int
+	var verb string
+	switch {
+	case must.Must2(cmd.Flags().GetBool(FlagUp)):
+		voteType, verb = sponsorblock.VoteUp, "Upvoted"
+	case must.Must2(cmd.Flags().GetBool(FlagDown)):
+		voteType, verb = sponsorblock.VoteDown, "Downvoted"
+	case must.Must2(cmd.Flags().GetBool(FlagUndo)):
+		voteType, verb = sponsorblock.VoteUndo, "Undid vote on"
+	default:
+		return ErrNoVoteType
+	}
+
+	if len(args) == 0 {
+		return ErrNoSegments
+	}
+
+	uuids := make([]string, 0, len(args))
+	for _, arg := range args {
+		uuid, err := resolve(entries, arg)
+		if err != nil {
+			return err
+		}
+		uuids = append(uuids, uuid)
+	}
+
+	var errs []error
+	for _, uuid := range uuids {
+		if err := sponsorblock.Vote(cmd.Context(), conf, uuid, voteType); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", uuid, err))
+			continue
+		}
+		if _, err := fmt.Fprintln(cmd.OutOrStdout(), verb, uuid); err != nil {
+			return err
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// resolve returns the UUID of a history index, or arg itself if it is not an index.
+func resolve(entries []history.Entry, arg string) (string, error) {
+	i, err := strconv.Atoi(arg)
+	if err != nil {
+		return arg, nil
+	}
+	if i < 1 || i > len(entries) {
+		return "", fmt.Errorf("%w %d", ErrHistoryIndex, i)
+	}
+	return entries[len(entries)-i].UUID, nil
+}
+
+func renderHistory(w io.Writer, entries []history.Entry) error {
+	if len(entries) == 0 {
+		_, err := io.WriteString(w, "No segments in history.\n")
+		return err
+	}
+
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"#", "Time", "Device", "Video", "Category", "Action", "Start", "End", "Seek Backs", "UUID"})
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		t.AppendRow(table.Row{
+			len(entries) - i,
+			entry.Time.Local().Format(time.DateTime),
+			entry.Device,
+			entry.VideoID,
+			entry.Category,
+			entry.Action,
+			formatSeconds(entry.Segment[0]),
+			formatSeconds(entry.Segment[1]),
+			entry.SeekBacks,
+			entry.UUID,
+		})
+	}
+
+	_, err := io.WriteString(w, t.Render()+"\n")
+	return err
+}
+
+func formatSeconds(seconds float32) string {
+	return time.Duration(float64(seconds) * float64(time.Second)).Round(time.Millisecond).String()
+}
@@ -0,0 +1,91 @@
+package vote
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"gabe565.com/castsponsorskip/internal/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVote(t *testing.T) {
+	var votes []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		votes = append(votes, r.URL.Query())
+	}))
+	t.Cleanup(server.Close)
+
+	conf := config.New()
+	conf.SponsorBlockUserID = "0123456789abcdef0123456789abcdef"
+	conf.SegmentHistoryFile = filepath.Join(t.TempDir(), "segment-history.json")
+	var err error
+	conf.SponsorBlockServer, err = url.Parse(server.URL)
+	require.NoError(t, err)
+
+	for _, uuid := range []string{"older", "newer"} {
+		require.NoError(t, history.Add(conf.SegmentHistoryFile, history.Entry{
+			Device:   "Living Room TV",
+			VideoID:  "dQw4w9WgXcQ",
+			UUID:     uuid,
+			Category: "sponsor",
+			Action:   "skip",
+		}))
+	}
+
+	execute := func(args ...string) (string, error) {
+		cmd := New()
+		cmd.SetContext(config.NewContext(t.Context(), conf))
+		cmd.SetArgs(args)
+		var out strings.Builder
+		cmd.SetOut(&out)
+		cmd.SetErr(&out)
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	t.Run("history", func(t *testing.T) {
+		out, err := execute("--history")
+		require.NoError(t, err)
+		assert.Less(t, strings.Index(out, "newer"), strings.Index(out, "older"))
+		assert.Empty(t, votes)
+	})
+
+	t.Run("down", func(t *testing.T) {
+		votes = nil
+		out, err := execute("--down", "1", "raw-uuid")
+		require.NoError(t, err)
+		assert.Contains(t, out, "Downvoted newer")
+		require.Len(t, votes, 2)
+		assert.Equal(t, "newer", votes[0].Get("UUID"))
+		assert.Equal(t, "0", votes[0].Get("type"))
+		assert.Equal(t, "raw-uuid", votes[1].Get("UUID"))
+	})
+
+	t.Run("up", func(t *testing.T) {
+		votes = nil
+		_, err := execute("--up", "2")
+		require.NoError(t, err)
+		require.Len(t, votes, 1)
+		assert.Equal(t, "older", votes[0].Get("UUID"))
+		assert.Equal(t, "1", votes[0].Get("type"))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		votes = nil
+		_, err := execute("1")
+		require.ErrorIs(t, err, ErrNoVoteType)
+		_, err = execute("--down")
+		require.ErrorIs(t, err, ErrNoSegments)
+		_, err = execute("--down", "3")
+		require.ErrorIs(t, err, ErrHistoryIndex)
+		_, err = execute("--down", "--up", "1")
+		require.Error(t, err)
+		assert.Empty(t, votes)
+	})
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+var ErrInvalidBackend = errors.New("invalid video ID backend")
+
+const (
+	BackendYouTube   = "youtube"
+	BackendInvidious = "invidious"
+	BackendPiped     = "piped"
+)
+
+//nolint:gochecknoglobals
+var Backends = []string{BackendYouTube, BackendInvidious, BackendPiped}
+
+// VideoIDBackend describes a service used to find the video ID of a device that does not report one.
+type VideoIDBackend struct {
+	Type string
+	URL  *url.URL
+}
+
+// ParseVideoIDBackend parses a backend rule.
+// Rules are "youtube", which uses the YouTube Data API, or "kind:url", where kind is invidious or piped
+// and url is the base URL of an instance API.
+func ParseVideoIDBackend(s string) (VideoIDBackend, error) {
+	s = strings.TrimSpace(s)
+	kind, rawURL, _ := strings.Cut(s, ":")
+	b := VideoIDBackend{Type: strings.ToLower(kind)}
+
+	switch b.Type {
+	case BackendYouTube:
+		if rawURL != "" {
+			return b, fmt.Errorf("%w: %q: %s does not accept a URL", ErrInvalidBackend, s, b.Type)
+		}
+	case BackendInvidious, BackendPiped:
+		u, err := url.Parse(rawURL)
+		switch {
+		case err != nil:
+			return b, fmt.Errorf("%w: %q: %w", ErrInvalidBackend, s, err)
+		case u.Scheme != "http" && u.Scheme != "https", u.Host == "":
+			return b, fmt.Errorf("%w: %q: expected %s:https://host", ErrInvalidBackend, s, b.Type)
+		}
+		b.URL = u
+	default:
+		err := fmt.Errorf("%w: %q: unknown backend %q", ErrInvalidBackend, s, b.Type)
+		if suggestion := suggest(b.Type, Backends); suggestion != "" {
+			err = fmt.Errorf("%w; did you mean %q?", err, suggestion)
+		}
+		return b, err
+	}
+
+	return b, nil
+}
+
+func (b VideoIDBackend) String() string {
+	if b.URL == nil {
+		return b.Type
+	}
+	return b.Type + ":" + b.URL.String()
+}
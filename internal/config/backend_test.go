@@ -0,0 +1,60 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVideoIDBackend(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr require.ErrorAssertionFunc
+	}{
+		{"youtube", "youtube", require.NoError},
+		{" YouTube ", "youtube", require.NoError},
+		{"invidious:https://yewtu.be", "invidious:https://yewtu.be", require.NoError},
+		{"piped:http://localhost:8080/api", "piped:http://localhost:8080/api", require.NoError},
+		{"youtube:https://www.youtube.com", "", require.Error},
+		{"invidious", "", require.Error},
+		{"invidious:yewtu.be", "", require.Error},
+		{"https://yewtu.be", "", require.Error},
+		{"pipe:https://pipedapi.example.com", "", require.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseVideoIDBackend(tt.input)
+			tt.wantErr(t, err)
+			if err != nil {
+				require.ErrorIs(t, err, ErrInvalidBackend)
+				return
+			}
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func TestLoad_VideoIDBackends(t *testing.T) {
+	setupConfigDirs(t)
+	t.Setenv("CSS_VIDEO_ID_BACKENDS", "invidious:https://yewtu.be,pipe:https://pipedapi.example.com")
+
+	cmd := &cobra.Command{}
+	RegisterFlags(cmd)
+	require.NoError(t, cmd.ParseFlags(nil))
+
+	_, err := Load(cmd)
+	require.ErrorIs(t, err, ErrInvalidBackend)
+	assert.Contains(t, err.Error(), "video-id-backends (from env)")
+	assert.Contains(t, err.Error(), `did you mean "piped"?`)
+
+	t.Setenv("CSS_VIDEO_ID_BACKENDS", "invidious:https://yewtu.be,youtube")
+	conf, err := Load(cmd)
+	require.NoError(t, err)
+	require.Len(t, conf.VideoIDBackendSpecs, 2)
+	assert.Equal(t, BackendInvidious, conf.VideoIDBackendSpecs[0].Type)
+	assert.Equal(t, "yewtu.be", conf.VideoIDBackendSpecs[0].URL.Host)
+	assert.Equal(t, BackendYouTube, conf.VideoIDBackendSpecs[1].Type)
+}
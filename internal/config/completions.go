@@ -29,6 +29,7 @@ func RegisterCompletions(cmd *cobra.Command) {
 		),
 	)
 	must.Must(cmd.RegisterFlagCompletionFunc(names.FlagNetworkInterface, completeNetworkInterface))
+	must.Must(cmd.RegisterFlagCompletionFunc(names.FlagExcludeNetworkInterface, completeNetworkInterface))
 	must.Must(
 		cmd.RegisterFlagCompletionFunc(
 			names.FlagDiscoverInterval,
@@ -93,6 +94,7 @@ func RegisterCompletions(cmd *cobra.Command) {
 		),
 	)
 	must.Must(cmd.RegisterFlagCompletionFunc(names.FlagCategories, completeCategories))
+	must.Must(cmd.RegisterFlagCompletionFunc(names.FlagAudioCategories, completeCategories))
 	must.Must(
 		cmd.RegisterFlagCompletionFunc(
 			names.FlagActionTypes,
@@ -101,6 +103,26 @@ func RegisterCompletions(cmd *cobra.Command) {
 			},
 		),
 	)
+	must.Must(
+		cmd.RegisterFlagCompletionFunc(
+			names.FlagSponsorBlockLookup,
+			func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+				return Lookups, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+			},
+		),
+	)
+	must.Must(
+		cmd.RegisterFlagCompletionFunc(
+			names.FlagVideoIDBackends,
+			func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+				return []string{
+					BackendYouTube,
+					BackendInvidious + ":https://",
+					BackendPiped + ":https://",
+				}, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveKeepOrder
+			},
+		),
+	)
 }
 
 func completeNetworkInterface(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
@@ -3,35 +3,80 @@ package config
 import (
 	"log/slog"
 	"net"
+	"net/url"
 	"strings"
 	"time"
+	"unicode"
 
 	"gabe565.com/castsponsorskip/internal/config/names"
+	"gabe565.com/castsponsorskip/internal/logsink"
 	"github.com/spf13/cobra"
 	castdns "github.com/vishen/go-chromecast/dns"
 )
 
 type Config struct {
-	LogLevel  string `yaml:"log-level"`
-	LogFormat string `yaml:"log-format"`
+	ConfigFiles []string          `yaml:"-"`
+	Sources     map[string]Source `yaml:"-"`
+
+	LogLevel     string         `yaml:"log-level"`
+	LogFormat    string         `yaml:"log-format"`
+	LogSinks     []string       `yaml:"log-sinks"`
+	LogSinkSpecs []logsink.Spec `yaml:"-"`
+	LogLevels    []string       `yaml:"log-levels"`
+	logLevels    *logLevels     `yaml:"-"`
 
 	DeviceAddrStrs        []string            `yaml:"devices"`
 	DeviceAddrs           []castdns.CastEntry `yaml:"-"`
+	IncludeDevices        []string            `yaml:"include-devices"`
+	IncludeDeviceFilters  []DeviceFilter      `yaml:"-"`
+	ExcludeDevices        []string            `yaml:"exclude-devices"`
+	ExcludeDeviceFilters  []DeviceFilter      `yaml:"-"`
 	DiscoverInterval      time.Duration       `yaml:"discover-interval"`
 	PausedInterval        time.Duration       `yaml:"paused-interval"`
 	PlayingInterval       time.Duration       `yaml:"playing-interval"`
 	SkipDelay             time.Duration       `yaml:"skip-delay"`
 	IgnoreSegmentDuration time.Duration       `yaml:"ignore-segment-duration"`
 
-	NetworkInterfaceName string         `yaml:"network-interface"`
-	NetworkInterface     *net.Interface `yaml:"-"`
+	NetworkInterfaceNames        []string         `yaml:"network-interface"`
+	ExcludeNetworkInterfaceNames []string         `yaml:"exclude-network-interface"`
+	NetworkInterfaces            []*net.Interface `yaml:"-"`
 
 	SkipSponsors bool     `yaml:"skip-sponsors"`
 	Categories   []string `yaml:"categories"`
 	ActionTypes  []string `yaml:"action-types"`
 
-	YouTubeAPIKey string `yaml:"youtube-api-key"`
-	MuteAds       bool   `yaml:"mute-ads"`
+	SponsorBlockURL        string   `yaml:"sponsorblock-url"`
+	SponsorBlockServer     *url.URL `yaml:"-"`
+	SponsorBlockLookup     string   `yaml:"sponsorblock-lookup"`
+	SponsorBlockHashPrefix int      `yaml:"sponsorblock-hash-prefix"`
+	SponsorBlockDBFile     string   `yaml:"sponsorblock-db-file"`
+	SponsorBlockDBFallback bool     `yaml:"sponsorblock-db-fallback"`
+	SponsorBlockUserID     string   `yaml:"sponsorblock-user-id"      secret:"true"`
+	SponsorBlockUserIDFile string   `yaml:"sponsorblock-user-id-file"`
+	SegmentHistoryFile     string   `yaml:"segment-history-file"`
+	DownvotePrompt         bool     `yaml:"downvote-prompt"`
+	ReportSkips            bool     `yaml:"report-skips"`
+
+	AudioDevices    bool     `yaml:"audio-devices"`
+	AudioCategories []string `yaml:"audio-categories"`
+
+	YouTubeAPIKey     string `yaml:"youtube-api-key"      secret:"true"`
+	YouTubeAPIKeyFile string `yaml:"youtube-api-key-file"`
+	YouTubeAPIQuota   int    `yaml:"youtube-api-quota"`
+	MuteAds           bool   `yaml:"mute-ads"`
+	MuteStateFile     string `yaml:"mute-state-file"`
+
+	VideoIDBackends     []string         `yaml:"video-id-backends"`
+	VideoIDBackendSpecs []VideoIDBackend `yaml:"-"`
+
+	VideoIDCacheFile        string        `yaml:"video-id-cache-file"`
+	VideoIDCacheSize        int           `yaml:"video-id-cache-size"`
+	VideoIDCacheTTL         time.Duration `yaml:"video-id-cache-ttl"`
+	VideoIDCacheNegativeTTL time.Duration `yaml:"video-id-cache-negative-ttl"`
+
+	ControlListen string `yaml:"control-listen"`
+
+	OTLPEndpoint string `yaml:"otlp-endpoint"`
 }
 
 func New() *Config {
@@ -48,22 +93,73 @@ func New() *Config {
 		Categories:   []string{"sponsor"},
 		ActionTypes:  []string{"skip", "mute"},
 
-		MuteAds: true,
+		SponsorBlockURL:        DefaultSponsorBlockURL,
+		SponsorBlockLookup:     LookupHash,
+		SponsorBlockHashPrefix: 4,
+
+		AudioCategories: []string{"sponsor", "music_offtopic"},
+
+		YouTubeAPIQuota: 10000,
+		MuteAds:         true,
+
+		VideoIDBackends: []string{BackendYouTube},
+
+		VideoIDCacheSize:        1000,
+		VideoIDCacheTTL:         30 * 24 * time.Hour,
+		VideoIDCacheNegativeTTL: 24 * time.Hour,
 	}
 }
 
+// YouTubeAPIKeys returns each configured YouTube API key.
+// Keys are separated by commas or whitespace.
+func (c *Config) YouTubeAPIKeys() []string {
+	return strings.FieldsFunc(c.YouTubeAPIKey, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+}
+
+func (c *Config) ForAudioDevice() *Config {
+	audio := *c
+	audio.Categories = c.AudioCategories
+	return &audio
+}
+
 func RegisterFlags(cmd *cobra.Command) {
-	fs := cmd.Flags()
+	fs := cmd.PersistentFlags()
 	c := New()
 
-	fs.String(names.FlagConfig, "", "Config file path")
+	fs.String(names.FlagConfig, "", "Config file path (replaces the user config file)")
 	fs.String(names.FlagLogLevel, c.LogLevel, "Log level (one of: debug, info, warn, error, none)")
 	fs.String(names.FlagLogFormat, c.LogFormat, "Log format (one of: "+strings.Join(LogFormatStrings(), ", ")+")")
+	fs.StringSlice(
+		names.FlagLogSinks,
+		c.LogSinks,
+		"Comma-separated list of additional log destinations, each with its own level (for example file:///var/log/castsponsorskip.log?level=debug, syslog+udp://localhost:514 or journald://)",
+	)
+	fs.StringSlice(
+		names.FlagLogLevels,
+		c.LogLevels,
+		"Comma-separated list of log level overrides. "+
+			"Rules are subsystem=level (one of: "+strings.Join(Subsystems, ", ")+") or device:name-or-uuid=level. "+
+			"Device rules take precedence. Reloaded on SIGHUP.",
+	)
 
 	fs.StringSlice(
 		names.FlagDevices,
 		c.DeviceAddrStrs,
-		"Comma-separated list of device addresses. This will disable discovery and is not recommended unless discovery fails",
+		"Comma-separated list of device addresses. This will disable discovery and is not recommended unless discovery fails. Device filters are not applied to these addresses",
+	)
+	fs.StringSlice(
+		names.FlagIncludeDevices,
+		c.IncludeDevices,
+		"Only watch discovered devices matching one of these rules. "+
+			"Rules match the friendly name by default, or can be prefixed with name:, uuid:, model: or ip:. "+
+			"Names and models accept globs, or regular expressions wrapped in slashes. IPs accept CIDRs.",
+	)
+	fs.StringSlice(
+		names.FlagExcludeDevices,
+		c.ExcludeDevices,
+		"Never watch discovered devices matching one of these rules. Uses the same syntax as --"+names.FlagIncludeDevices,
 	)
 	fs.Duration(names.FlagDiscoverInterval, c.DiscoverInterval, "Interval to restart the DNS discovery client")
 	fs.Duration(names.FlagPausedInterval, c.PausedInterval, "Interval to scan paused devices")
@@ -75,11 +171,16 @@ func RegisterFlags(cmd *cobra.Command) {
 		"Ignores the previous sponsored segment for a set amount of time. Useful if you want to to go back and watch a segment.",
 	)
 
-	fs.StringP(
+	fs.StringSliceP(
 		names.FlagNetworkInterface,
 		"i",
-		c.NetworkInterfaceName,
-		"Network interface to use for multicast dns discovery. (default all interfaces)",
+		c.NetworkInterfaceNames,
+		"Comma-separated list of network interfaces to use for multicast dns discovery. (default all interfaces)",
+	)
+	fs.StringSlice(
+		names.FlagExcludeNetworkInterface,
+		c.ExcludeNetworkInterfaceNames,
+		"Comma-separated list of network interfaces to exclude from multicast dns discovery",
 	)
 
 	fs.Bool(names.FlagSkipSponsors, c.SkipSponsors, "Skip sponsored segments with SponsorBlock")
@@ -90,10 +191,121 @@ func RegisterFlags(cmd *cobra.Command) {
 		"SponsorBlock action types to handle. Shorter segments that overlap with content can be muted instead of skipped.",
 	)
 
+	fs.String(names.FlagSponsorBlockURL, c.SponsorBlockURL, "SponsorBlock server URL")
+	fs.String(
+		names.FlagSponsorBlockLookup,
+		c.SponsorBlockLookup,
+		"How to request segments (one of: "+strings.Join(Lookups, ", ")+"). "+
+			"hash sends a prefix of the video ID's SHA-256 hash so the server can't tell which video is playing. "+
+			"direct sends the video ID, which returns smaller responses and is useful for self-hosted servers. "+
+			"local reads a database created by the import-db command.",
+	)
+	fs.Int(
+		names.FlagSponsorBlockHashPrefix,
+		c.SponsorBlockHashPrefix,
+		"Number of hash characters to send in hash lookup mode, from 3 to 32. Shorter prefixes are more private but return more unrelated videos",
+	)
+	fs.String(
+		names.FlagSponsorBlockDBFile,
+		c.SponsorBlockDBFile,
+		"Path to the database created by the import-db command (default sponsorblock.db in the user cache directory)",
+	)
+	fs.Bool(
+		names.FlagSponsorBlockDBFallback,
+		c.SponsorBlockDBFallback,
+		"In local lookup mode, query the SponsorBlock server for videos that are not in the database",
+	)
+	fs.String(
+		names.FlagSponsorBlockUserID,
+		c.SponsorBlockUserID,
+		"Private SponsorBlock user ID used to submit segments from the control API. Keep it secret, it identifies you to SponsorBlock",
+	)
+	fs.String(
+		names.FlagSponsorBlockUserIDFile,
+		c.SponsorBlockUserIDFile,
+		"Path to a file containing the private SponsorBlock user ID",
+	)
+	fs.String(
+		names.FlagSegmentHistoryFile,
+		c.SegmentHistoryFile,
+		"Path to the history of skipped and muted segments used by the vote command (default segment-history.json in the user cache directory)",
+	)
+	fs.Bool(
+		names.FlagDownvotePrompt,
+		c.DownvotePrompt,
+		"Suggest downvoting a segment when playback is repeatedly sought back into it after it was skipped",
+	)
+	fs.Bool(
+		names.FlagReportSkips,
+		c.ReportSkips,
+		"Report skipped segments to SponsorBlock like the browser extension does, which counts segment views for their submitters",
+	)
+
+	fs.Bool(
+		names.FlagAudioDevices,
+		c.AudioDevices,
+		"Watch audio-only devices like smart speakers and speaker groups",
+	)
+	fs.StringSlice(
+		names.FlagAudioCategories,
+		c.AudioCategories,
+		"Comma-separated list of SponsorBlock categories to skip on audio-only devices",
+	)
+
 	fs.String(
 		names.FlagYouTubeAPIKey,
 		c.YouTubeAPIKey,
-		"YouTube API key for fallback video identification (required on some Chromecast devices).",
+		"YouTube API key for fallback video identification (required on some Chromecast devices). "+
+			"Accepts a comma-separated list of keys to rotate through when one runs out of quota.",
+	)
+	fs.String(
+		names.FlagYouTubeAPIKeyFile,
+		c.YouTubeAPIKeyFile,
+		"Path to a file containing the YouTube API key",
+	)
+	fs.Int(
+		names.FlagYouTubeAPIQuota,
+		c.YouTubeAPIQuota,
+		"Daily quota units per YouTube API key. Keys are rotated when their estimated use would exceed it",
 	)
 	fs.Bool(names.FlagMuteAds, c.MuteAds, "Mutes the device while an ad is playing")
+	fs.String(
+		names.FlagMuteStateFile,
+		c.MuteStateFile,
+		"Path to the list of devices muted by CastSponsorSkip, used to unmute them after a crash (default mute-state.json in the user cache directory)",
+	)
+	fs.StringSlice(
+		names.FlagVideoIDBackends,
+		c.VideoIDBackends,
+		"Comma-separated list of services to search, in order, for devices that do not report a video ID. "+
+			"One of: youtube (requires an API key), invidious:URL or piped:URL",
+	)
+	fs.String(
+		names.FlagVideoIDCacheFile,
+		c.VideoIDCacheFile,
+		"Path to the video ID search cache (default video-ids.json in the user cache directory)",
+	)
+	fs.Int(
+		names.FlagVideoIDCacheSize,
+		c.VideoIDCacheSize,
+		"Number of video ID searches to cache. The least recently used searches are evicted first. Set to 0 to disable the cache",
+	)
+	fs.Duration(names.FlagVideoIDCacheTTL, c.VideoIDCacheTTL, "How long to cache video ID search results")
+	fs.Duration(
+		names.FlagVideoIDCacheNegativeTTL,
+		c.VideoIDCacheNegativeTTL,
+		"How long to cache searches that found no matching video. Set to 0 to always search again",
+	)
+
+	fs.String(
+		names.FlagControlListen,
+		c.ControlListen,
+		"Address to serve the control API on (for example 127.0.0.1:8081), used to submit segments from the couch. Disabled if empty",
+	)
+
+	fs.String(
+		names.FlagOTLPEndpoint,
+		c.OTLPEndpoint,
+		"OTLP/HTTP endpoint to export traces to (for example http://localhost:4318). Tracing is disabled if empty",
+	)
 }
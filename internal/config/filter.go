@@ -0,0 +1,160 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"path"
+	"regexp"
+	"strings"
+
+	castdns "github.com/vishen/go-chromecast/dns"
+)
+
+var ErrInvalidDeviceFilter = errors.New("invalid device filter")
+
+const (
+	FilterName  = "name"
+	FilterUUID  = "uuid"
+	FilterModel = "model"
+	FilterIP    = "ip"
+)
+
+type DeviceFilter struct {
+	raw   string
+	kind  string
+	uuid  string
+	glob  string
+	re    *regexp.Regexp
+	ipNet *net.IPNet
+}
+
+// ParseDeviceFilter parses a device filter rule.
+// Rules have the form "kind:value", where kind is one of name, uuid, model or ip.
+// Rules without a kind match the friendly name.
+// Name and model values are case-insensitive globs, or regular expressions when wrapped in slashes.
+// IP values may be a single address or a CIDR.
+func ParseDeviceFilter(s string) (DeviceFilter, error) {
+	s = strings.TrimSpace(s)
+	f := DeviceFilter{raw: s, kind: FilterName}
+
+	value := s
+	if kind, v, ok := strings.Cut(s, ":"); ok {
+		switch kind := strings.ToLower(kind); kind {
+		case FilterName, FilterUUID, FilterModel, FilterIP:
+			f.kind = kind
+			value = v
+		}
+	}
+
+	if value == "" {
+		return f, fmt.Errorf("%w: %q: empty value", ErrInvalidDeviceFilter, s)
+	}
+
+	switch f.kind {
+	case FilterIP:
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return f, fmt.Errorf("%w: %q: %w", ErrInvalidDeviceFilter, s, ErrInvalidIP)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				bits = 8 * net.IPv4len
+			}
+			f.ipNet = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+			break
+		}
+
+		_, ipNet, err := net.ParseCIDR(value)
+		if err != nil {
+			return f, fmt.Errorf("%w: %q: %w", ErrInvalidDeviceFilter, s, err)
+		}
+		f.ipNet = ipNet
+	case FilterUUID:
+		f.uuid = normalizeUUID(value)
+	default:
+		if len(value) > 1 && strings.HasPrefix(value, "/") && strings.HasSuffix(value, "/") {
+			re, err := regexp.Compile("(?i)" + value[1:len(value)-1])
+			if err != nil {
+				return f, fmt.Errorf("%w: %q: %w", ErrInvalidDeviceFilter, s, err)
+			}
+			f.re = re
+			break
+		}
+
+		f.glob = strings.ToLower(value)
+		if _, err := path.Match(f.glob, ""); err != nil {
+			return f, fmt.Errorf("%w: %q: %w", ErrInvalidDeviceFilter, s, err)
+		}
+	}
+
+	return f, nil
+}
+
+func (f DeviceFilter) String() string {
+	return f.raw
+}
+
+func (f DeviceFilter) Match(entry castdns.CastEntry) bool {
+	switch f.kind {
+	case FilterIP:
+		return (entry.AddrV4 != nil && f.ipNet.Contains(entry.AddrV4)) ||
+			(entry.AddrV6 != nil && f.ipNet.Contains(entry.AddrV6))
+	case FilterUUID:
+		return normalizeUUID(entry.UUID) == f.uuid
+	case FilterModel:
+		return f.matchString(entry.Device)
+	default:
+		return f.matchString(entry.DeviceName)
+	}
+}
+
+func (f DeviceFilter) matchString(s string) bool {
+	if f.re != nil {
+		return f.re.MatchString(s)
+	}
+	ok, _ := path.Match(f.glob, strings.ToLower(s))
+	return ok
+}
+
+func normalizeUUID(s string) string {
+	return strings.ToLower(strings.ReplaceAll(s, "-", ""))
+}
+
+func parseDeviceFilters(rules []string) ([]DeviceFilter, error) {
+	filters := make([]DeviceFilter, 0, len(rules))
+	var errs []error
+	for _, rule := range rules {
+		if strings.TrimSpace(rule) == "" {
+			continue
+		}
+		f, err := ParseDeviceFilter(rule)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		filters = append(filters, f)
+	}
+	return filters, errors.Join(errs...)
+}
+
+// AllowDevice reports whether a discovered device passes the include and exclude filters.
+// If the device is rejected, the matching rule is returned as the reason.
+func (c *Config) AllowDevice(entry castdns.CastEntry) (bool, string) {
+	for _, f := range c.ExcludeDeviceFilters {
+		if f.Match(entry) {
+			return false, "excluded by " + f.String()
+		}
+	}
+
+	if len(c.IncludeDeviceFilters) == 0 {
+		return true, ""
+	}
+	for _, f := range c.IncludeDeviceFilters {
+		if f.Match(entry) {
+			return true, ""
+		}
+	}
+	return false, "not included"
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	castdns "github.com/vishen/go-chromecast/dns"
+)
+
+func TestDeviceFilter_Match(t *testing.T) {
+	entry := castdns.CastEntry{
+		UUID:       "0123abcd-4567-89ef-0123-456789abcdef",
+		DeviceName: "Conference Room",
+		Device:     "Chromecast Ultra",
+		AddrV4:     net.IPv4(10, 0, 20, 15),
+	}
+
+	tests := []struct {
+		rule string
+		want bool
+	}{
+		{"Conference Room", true},
+		{"conference*", true},
+		{"name:Living*", false},
+		{"name:/^Conf.+Room$/", true},
+		{"/^conf/", true},
+		{"/^Living/", false},
+		{"uuid:0123ABCD456789EF0123456789ABCDEF", true},
+		{"uuid:0123abcd", false},
+		{"model:chromecast*", true},
+		{"model:/Nest/", false},
+		{"model:/ULTRA$/", true},
+		{"ip:10.0.20.15", true},
+		{"ip:10.0.20.0/24", true},
+		{"ip:192.168.0.0/16", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			f, err := ParseDeviceFilter(tt.rule)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, f.Match(entry))
+		})
+	}
+}
+
+func TestParseDeviceFilter_Invalid(t *testing.T) {
+	for _, rule := range []string{"name:", "ip:abc", "ip:10.0.0.0/99", "name:/(/", "model:[a"} {
+		t.Run(rule, func(t *testing.T) {
+			_, err := ParseDeviceFilter(rule)
+			require.ErrorIs(t, err, ErrInvalidDeviceFilter)
+		})
+	}
+}
+
+func TestConfig_AllowDevice(t *testing.T) {
+	office := castdns.CastEntry{DeviceName: "Conference Room", AddrV4: net.IPv4(10, 0, 20, 15)}
+	tv := castdns.CastEntry{DeviceName: "Living Room TV", AddrV4: net.IPv4(192, 168, 1, 5)}
+
+	c := New()
+	ok, _ := c.AllowDevice(office)
+	assert.True(t, ok)
+
+	c.ExcludeDeviceFilters, _ = parseDeviceFilters([]string{"Conference Room"})
+	ok, reason := c.AllowDevice(office)
+	assert.False(t, ok)
+	assert.Contains(t, reason, "Conference Room")
+	ok, _ = c.AllowDevice(tv)
+	assert.True(t, ok)
+
+	c.ExcludeDeviceFilters = nil
+	c.IncludeDeviceFilters, _ = parseDeviceFilters([]string{"ip:192.168.1.0/24"})
+	ok, _ = c.AllowDevice(office)
+	assert.False(t, ok)
+	ok, _ = c.AllowDevice(tv)
+	assert.True(t, ok)
+}
@@ -3,15 +3,15 @@ package config
 import (
 	"errors"
 	"fmt"
+	"log/slog"
 	"net"
 	"net/url"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 
 	"gabe565.com/castsponsorskip/internal/config/names"
 	"gabe565.com/castsponsorskip/internal/config/sponsorblockcast"
+	"gabe565.com/castsponsorskip/internal/logsink"
 	"gabe565.com/utils/must"
 	"github.com/knadh/koanf/parsers/yaml"
 	"github.com/knadh/koanf/providers/env/v2"
@@ -20,6 +20,7 @@ import (
 	"github.com/knadh/koanf/providers/structs"
 	"github.com/knadh/koanf/v2"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	castdns "github.com/vishen/go-chromecast/dns"
 )
 
@@ -28,66 +29,227 @@ const EnvPrefix = "CSS_"
 var ErrInvalidIP = errors.New("failed to parse IP")
 
 func Load(cmd *cobra.Command) (*Config, error) {
+	c, err := load(cmd)
+	if c != nil {
+		c.InitLog(cmd.ErrOrStderr())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.openLogSinks(); err != nil {
+		return nil, err
+	}
+	c.InitLog(cmd.ErrOrStderr())
+
+	return c, nil
+}
+
+// Resolve loads and validates the config without opening log sinks or changing the logger.
+// It is used by commands that only inspect the config.
+func Resolve(cmd *cobra.Command) (*Config, error) {
+	c, err := load(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reload loads the config again and applies its log levels.
+// Other changes take effect after a restart.
+func Reload(cmd *cobra.Command) error {
+	c, err := load(cmd)
+	if err != nil {
+		return err
+	}
+
+	level, err := parseLevel(c.LogLevel)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	c.logLevels.level = level
+	currentLogLevels.Store(c.logLevels)
+	return nil
+}
+
+// LoadFile loads a single config file over the defaults and validates it.
+// Other config files, envs and flags are ignored, and the logger is not changed.
+func LoadFile(path string) (*Config, error) {
+	return loadFrom(nil, []string{path})
+}
+
+// load reads every config source without changing the logger.
+// The returned config is non-nil if it was unmarshalled, even if it failed validation.
+func load(cmd *cobra.Command) (*Config, error) {
+	cfgFiles, err := ConfigPaths(must.Must2(cmd.Flags().GetString(names.FlagConfig)))
+	if err != nil {
+		return nil, err
+	}
+	return loadFrom(cmd, cfgFiles)
+}
+
+// loadFrom reads the defaults and cfgFiles, then envs and flags if cmd is not nil.
+func loadFrom(cmd *cobra.Command, cfgFiles []string) (*Config, error) {
 	k := koanf.New(".")
 	c := New()
+	c.Sources = make(map[string]Source)
 
 	// Load default config
-	if err := k.Load(structs.Provider(c, "yaml"), nil); err != nil {
+	if err := c.loadLayer(k, SourceDefault, structs.Provider(c, "yaml"), nil); err != nil {
 		return nil, err
 	}
 
-	// Find config file
-	cfgFiles := make([]string, 0, 4)
-	var fileRequired bool
-	if cfgFile := must.Must2(cmd.Flags().GetString(names.FlagConfig)); cfgFile != "" {
-		cfgFiles = append(cfgFiles, cfgFile)
-		fileRequired = true
-	} else {
-		var configDir string
-		if xdgConfigDir, err := os.UserConfigDir(); err == nil {
-			configDir = xdgConfigDir
-		} else {
-			home, err := os.UserHomeDir()
-			if err != nil {
-				return nil, err
-			}
+	// Load config files
+	parser := yaml.Parser()
+	for _, cfgFile := range cfgFiles {
+		if err := c.loadLayer(k, FileSource(cfgFile), file.Provider(cfgFile), parser); err != nil {
+			return nil, err
+		}
+	}
+	c.ConfigFiles = cfgFiles
 
-			configDir = filepath.Join(home, ".config")
+	if cmd != nil {
+		if err := c.loadOverrides(k, cmd); err != nil {
+			return nil, err
 		}
+	}
+
+	var problems ValidationError
+	c.loadSecretFiles(k, &problems)
 
-		cfgFiles = append(cfgFiles,
-			filepath.Join(configDir, "sponsorblockcast", "config.yaml"),
-			filepath.Join(configDir, "sponsorblockcast", "config.yml"),
-			filepath.Join("etc", "sponsorblockcast", "config.yaml"),
-			filepath.Join("etc", "sponsorblockcast", "config.yml"),
-		)
+	if err := k.UnmarshalWithConf("", &c, koanf.UnmarshalConf{Tag: "yaml"}); err != nil {
+		return nil, err
 	}
 
-	// Load config file
-	parser := yaml.Parser()
-	for _, cfgFile := range cfgFiles {
-		if err := k.Load(file.Provider(cfgFile), parser); err != nil {
-			if !fileRequired && errors.Is(err, os.ErrNotExist) {
+	for i, category := range c.Categories {
+		c.Categories[i] = strings.TrimSpace(category)
+	}
+
+	for i, category := range c.AudioCategories {
+		c.AudioCategories[i] = strings.TrimSpace(category)
+	}
+
+	for i, actionType := range c.ActionTypes {
+		c.ActionTypes[i] = strings.TrimSpace(actionType)
+	}
+
+	c.validateKeys(&problems)
+	c.validateValues(&problems)
+
+	if len(c.NetworkInterfaceNames) != 0 || len(c.ExcludeNetworkInterfaceNames) != 0 {
+		var err error
+		if c.NetworkInterfaces, err = resolveNetworkInterfaces(
+			c.NetworkInterfaceNames,
+			c.ExcludeNetworkInterfaceNames,
+		); err != nil {
+			problems.add(c, names.FlagNetworkInterface, err)
+		}
+	}
+
+	var err error
+	if c.IncludeDeviceFilters, err = parseDeviceFilters(c.IncludeDevices); err != nil {
+		problems.add(c, names.FlagIncludeDevices, err)
+	}
+	if c.ExcludeDeviceFilters, err = parseDeviceFilters(c.ExcludeDevices); err != nil {
+		problems.add(c, names.FlagExcludeDevices, err)
+	}
+
+	if len(c.DeviceAddrStrs) != 0 {
+		c.DeviceAddrs = make([]castdns.CastEntry, 0, len(c.DeviceAddrStrs))
+		for _, device := range c.DeviceAddrStrs {
+			if device == "" {
 				continue
 			}
-			return nil, err
+
+			castEntry, err := parseDeviceAddr(device)
+			if err != nil {
+				problems.add(c, names.FlagDevices, err)
+				continue
+			}
+			c.DeviceAddrs = append(c.DeviceAddrs, castEntry)
+		}
+	}
+
+	c.LogSinkSpecs = make([]logsink.Spec, 0, len(c.LogSinks))
+	for _, sink := range c.LogSinks {
+		if strings.TrimSpace(sink) == "" {
+			continue
+		}
+
+		spec, err := logsink.Parse(sink)
+		if err != nil {
+			problems.add(c, names.FlagLogSinks, err)
+			continue
 		}
-		break
+		c.LogSinkSpecs = append(c.LogSinkSpecs, spec)
 	}
 
+	if c.SponsorBlockServer, err = ParseSponsorBlockURL(c.SponsorBlockURL); err != nil {
+		problems.add(c, names.FlagSponsorBlockURL, err)
+	}
+
+	c.VideoIDBackendSpecs = make([]VideoIDBackend, 0, len(c.VideoIDBackends))
+	for _, backend := range c.VideoIDBackends {
+		if strings.TrimSpace(backend) == "" {
+			continue
+		}
+
+		spec, err := ParseVideoIDBackend(backend)
+		if err != nil {
+			problems.add(c, names.FlagVideoIDBackends, err)
+			continue
+		}
+		c.VideoIDBackendSpecs = append(c.VideoIDBackendSpecs, spec)
+	}
+
+	level, err := parseLevel(c.LogLevel)
+	if err != nil {
+		level = slog.LevelInfo
+	}
+	logLevels := newLogLevels(level)
+	for _, rule := range c.LogLevels {
+		if rule = strings.TrimSpace(rule); rule == "" {
+			continue
+		}
+
+		if err := logLevels.add(rule); err != nil {
+			problems.add(c, names.FlagLogLevels, err)
+		}
+	}
+
+	if len(problems) != 0 {
+		return c, problems
+	}
+
+	c.logLevels = logLevels
+	return c, nil
+}
+
+// loadOverrides loads deprecated envs, envs and flags over the config files.
+func (c *Config) loadOverrides(k *koanf.Koanf, cmd *cobra.Command) error {
 	// Load deprecated envs
-	if err := k.Load(sponsorblockcast.Provider(), nil); err != nil {
-		return nil, err
+	if err := c.loadLayer(k, SourceSponsorBlockCast, sponsorblockcast.Provider(), nil); err != nil {
+		return err
 	}
 
 	// Load envs
-	if err := k.Load(env.Provider(".", env.Opt{
+	if err := c.loadLayer(k, SourceEnv, env.Provider(".", env.Opt{
 		Prefix: EnvPrefix,
 		TransformFunc: func(k, v string) (string, any) {
 			k = strings.ReplaceAll(strings.ToLower(strings.TrimPrefix(k, EnvPrefix)), "_", "-")
 
 			switch k {
-			case names.FlagDevices, names.FlagCategories, names.FlagActionTypes:
+			case names.FlagLogSinks,
+				names.FlagLogLevels,
+				names.FlagVideoIDBackends,
+				names.FlagDevices,
+				names.FlagIncludeDevices,
+				names.FlagExcludeDevices,
+				names.FlagNetworkInterface,
+				names.FlagExcludeNetworkInterface,
+				names.FlagCategories,
+				names.FlagActionTypes,
+				names.FlagAudioCategories:
 				if v == "" {
 					return k, []string{}
 				}
@@ -97,70 +259,47 @@ func Load(cmd *cobra.Command) (*Config, error) {
 			}
 		},
 	}), nil); err != nil {
-		return nil, err
+		return err
 	}
 
 	// Load flags
 	if err := k.Load(posflag.Provider(cmd.Flags(), ".", k), nil); err != nil {
-		return nil, err
-	}
-
-	if err := k.UnmarshalWithConf("", &c, koanf.UnmarshalConf{Tag: "yaml"}); err != nil {
-		return nil, err
+		return err
 	}
-
-	c.InitLog(cmd.ErrOrStderr())
-
-	if c.NetworkInterfaceName != "" {
-		var err error
-		if c.NetworkInterface, err = net.InterfaceByName(c.NetworkInterfaceName); err != nil {
-			return nil, err
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		if _, ok := c.Sources[flag.Name]; ok {
+			c.Sources[flag.Name] = SourceFlag
 		}
-	}
+	})
+	return nil
+}
 
-	for i, category := range c.Categories {
-		c.Categories[i] = strings.TrimSpace(category)
-	}
+func parseDeviceAddr(device string) (castdns.CastEntry, error) {
+	u := url.URL{Host: device}
 
-	for i, actionType := range c.ActionTypes {
-		c.ActionTypes[i] = strings.TrimSpace(actionType)
+	castEntry := castdns.CastEntry{
+		DeviceName: device,
+		UUID:       device,
 	}
 
-	if len(c.DeviceAddrStrs) != 0 {
-		c.DeviceAddrs = make([]castdns.CastEntry, 0, len(c.DeviceAddrStrs))
-		for _, device := range c.DeviceAddrStrs {
-			if device == "" {
-				continue
-			}
-			u := url.URL{Host: device}
-
-			castEntry := castdns.CastEntry{
-				DeviceName: device,
-				UUID:       device,
-			}
-
-			if port := u.Port(); port == "" {
-				castEntry.Port = 8009
-			} else {
-				port, err := strconv.ParseUint(port, 10, 16)
-				if err != nil {
-					return nil, err
-				}
-
-				castEntry.Port = int(port)
-			}
+	if port := u.Port(); port == "" {
+		castEntry.Port = 8009
+	} else {
+		port, err := strconv.ParseUint(port, 10, 16)
+		if err != nil {
+			return castEntry, fmt.Errorf("%w: %q: %w", ErrInvalidIP, device, err)
+		}
 
-			if ip := net.ParseIP(u.Hostname()); ip == nil {
-				return nil, fmt.Errorf("%w: %q", ErrInvalidIP, device)
-			} else if ip.To4() != nil {
-				castEntry.AddrV4 = ip
-			} else {
-				castEntry.AddrV6 = ip
-			}
+		castEntry.Port = int(port)
+	}
 
-			c.DeviceAddrs = append(c.DeviceAddrs, castEntry)
-		}
+	if ip := net.ParseIP(u.Hostname()); ip == nil {
+		return castEntry, fmt.Errorf("%w: %q", ErrInvalidIP, device)
+	} else if ip.To4() != nil {
+		castEntry.AddrV4 = ip
+	} else {
+		castEntry.AddrV6 = ip
 	}
 
-	return c, nil
+	return castEntry, nil
 }
@@ -1,8 +1,11 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"sync"
 	"time"
 
 	"gabe565.com/utils/termx"
@@ -21,10 +24,8 @@ const (
 )
 
 func (c *Config) InitLog(w io.Writer) {
-	var level slog.Level
-	if c.LogLevel == "none" {
-		level = slog.LevelError + 1
-	} else if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+	level, err := parseLevel(c.LogLevel)
+	if err != nil {
 		slog.Warn("Invalid log level. Defaulting to info.", "value", c.LogLevel)
 		level = slog.LevelInfo
 		c.LogLevel = level.String()
@@ -37,15 +38,27 @@ func (c *Config) InitLog(w io.Writer) {
 		c.LogFormat = format.String()
 	}
 
+	if c.logLevels != nil {
+		levels := *c.logLevels
+		levels.level = level
+		currentLogLevels.Store(&levels)
+	}
 	InitLog(w, level, format)
 }
 
 func InitLog(w io.Writer, level slog.Level, format LogFormat) {
+	levels := *currentLogLevels.Load()
+	levels.level = level
+	currentLogLevels.Store(&levels)
+	slog.SetDefault(slog.New(LogHandler(consoleHandler(w, slog.LevelDebug, format))))
+}
+
+func consoleHandler(w io.Writer, level slog.Level, format LogFormat) slog.Handler {
 	switch format {
 	case FormatJSON:
-		slog.SetDefault(slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{
+		return slog.NewJSONHandler(w, &slog.HandlerOptions{
 			Level: level,
-		})))
+		})
 	default:
 		var color bool
 		switch format {
@@ -55,12 +68,70 @@ func InitLog(w io.Writer, level slog.Level, format LogFormat) {
 			color = true
 		}
 
-		slog.SetDefault(slog.New(
-			tint.NewHandler(w, &tint.Options{
-				Level:      level,
-				TimeFormat: time.DateTime,
-				NoColor:    !color,
-			}),
-		))
+		return tint.NewHandler(w, &tint.Options{
+			Level:      level,
+			TimeFormat: time.DateTime,
+			NoColor:    !color,
+		})
+	}
+}
+
+//nolint:gochecknoglobals
+var (
+	logSinks   []slog.Handler
+	logClosers []io.Closer
+	logSinksMu sync.Mutex
+)
+
+// LogHandler returns a handler that writes to console and every open log sink.
+// Console records are filtered by the global log level and any overrides.
+func LogHandler(console slog.Handler) slog.Handler {
+	var handler slog.Handler = newLevelHandler(console, globalLogLevel)
+	logSinksMu.Lock()
+	defer logSinksMu.Unlock()
+	if len(logSinks) == 0 {
+		return handler
+	}
+	return slog.NewMultiHandler(append([]slog.Handler{handler}, logSinks...)...)
+}
+
+func (c *Config) openLogSinks() error {
+	if err := CloseLogSinks(); err != nil {
+		return err
+	}
+
+	handlers := make([]slog.Handler, 0, len(c.LogSinkSpecs))
+	closers := make([]io.Closer, 0, len(c.LogSinkSpecs))
+	for _, spec := range c.LogSinkSpecs {
+		// The sink accepts every level so overrides can lower it
+		level := spec.Level
+		spec.Level = slog.LevelDebug
+		handler, closer, err := spec.Open()
+		if err != nil {
+			for _, closer := range closers {
+				_ = closer.Close()
+			}
+			return fmt.Errorf("failed to open log sink %q: %w", spec.String(), err)
+		}
+		handlers = append(handlers, newLevelHandler(handler, level.Level))
+		closers = append(closers, closer)
+	}
+
+	logSinksMu.Lock()
+	defer logSinksMu.Unlock()
+	logSinks, logClosers = handlers, closers
+	return nil
+}
+
+// CloseLogSinks closes every open log sink.
+func CloseLogSinks() error {
+	logSinksMu.Lock()
+	defer logSinksMu.Unlock()
+
+	errs := make([]error, 0, len(logClosers))
+	for _, closer := range logClosers {
+		errs = append(errs, closer.Close())
 	}
+	logSinks, logClosers = nil, nil
+	return errors.Join(errs...)
 }
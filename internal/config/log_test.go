@@ -0,0 +1,51 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gabe565.com/castsponsorskip/internal/logsink"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_LogSinks(t *testing.T) {
+	setupConfigDirs(t)
+	path := filepath.Join(t.TempDir(), "castsponsorskip.log")
+	t.Setenv("CSS_LOG_SINKS", "file://"+path+"?level=debug")
+	t.Cleanup(func() {
+		_ = CloseLogSinks()
+		InitLog(os.Stderr, slog.LevelInfo, FormatAuto)
+	})
+
+	cmd := &cobra.Command{}
+	RegisterFlags(cmd)
+	require.NoError(t, cmd.ParseFlags([]string{"--log-level=error"}))
+
+	conf, err := Load(cmd)
+	require.NoError(t, err)
+	require.Len(t, conf.LogSinkSpecs, 1)
+
+	slog.Debug("Written to file only.")
+	require.NoError(t, CloseLogSinks())
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "Written to file only.")
+}
+
+func TestLoad_LogSinksInvalid(t *testing.T) {
+	setupConfigDirs(t)
+	t.Setenv("CSS_LOG_SINKS", "kafka://localhost")
+
+	cmd := &cobra.Command{}
+	RegisterFlags(cmd)
+	require.NoError(t, cmd.ParseFlags(nil))
+
+	_, err := Load(cmd)
+	require.ErrorIs(t, err, logsink.ErrInvalidSink)
+	assert.Contains(t, err.Error(), "log-sinks (from env)")
+}
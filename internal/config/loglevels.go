@@ -0,0 +1,187 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+var ErrInvalidLogLevelRule = errors.New("invalid log level rule")
+
+const (
+	SubsystemDiscovery    = "discovery"
+	SubsystemSponsorBlock = "sponsorblock"
+	SubsystemYouTube      = "youtube"
+	SubsystemTick         = "tick"
+	SubsystemCast         = "cast"
+
+	// LogKeySubsystem and LogKeyDeviceUUID select log level overrides.
+	// They are removed from log output.
+	LogKeySubsystem  = "subsystem"
+	LogKeyDeviceUUID = "device_uuid"
+
+	logLevelDevicePrefix = "device:"
+)
+
+//nolint:gochecknoglobals
+var Subsystems = []string{
+	SubsystemDiscovery,
+	SubsystemSponsorBlock,
+	SubsystemYouTube,
+	SubsystemTick,
+	SubsystemCast,
+}
+
+// Logger returns the default logger tagged with a subsystem.
+func Logger(subsystem string) *slog.Logger {
+	return slog.Default().With(LogKeySubsystem, subsystem)
+}
+
+type logLevels struct {
+	level      slog.Level
+	devices    map[string]slog.Level
+	subsystems map[string]slog.Level
+}
+
+//nolint:gochecknoglobals
+var currentLogLevels atomic.Pointer[logLevels]
+
+func init() { //nolint:gochecknoinits
+	currentLogLevels.Store(newLogLevels(slog.LevelInfo))
+}
+
+func newLogLevels(level slog.Level) *logLevels {
+	return &logLevels{
+		level:      level,
+		devices:    make(map[string]slog.Level),
+		subsystems: make(map[string]slog.Level),
+	}
+}
+
+// add parses a rule of the form "subsystem=level" or "device:name-or-uuid=level".
+// Device names are matched case-insensitively.
+func (l *logLevels) add(rule string) error {
+	target, levelStr, ok := strings.Cut(rule, "=")
+	if !ok {
+		return fmt.Errorf("%w: %q: expected target=level", ErrInvalidLogLevelRule, rule)
+	}
+
+	level, err := parseLevel(strings.TrimSpace(levelStr))
+	if err != nil {
+		return fmt.Errorf("%w: %q: %w", ErrInvalidLogLevelRule, rule, err)
+	}
+
+	target = strings.TrimSpace(target)
+	if device, ok := strings.CutPrefix(target, logLevelDevicePrefix); ok {
+		if device == "" {
+			return fmt.Errorf("%w: %q: empty device", ErrInvalidLogLevelRule, rule)
+		}
+		l.devices[strings.ToLower(device)] = level
+		l.devices[normalizeUUID(device)] = level
+		return nil
+	}
+
+	target = strings.ToLower(target)
+	if !slices.Contains(Subsystems, target) {
+		err := fmt.Errorf("%w: %q: unknown subsystem %q", ErrInvalidLogLevelRule, rule, target)
+		if suggestion := suggest(target, Subsystems); suggestion != "" {
+			err = fmt.Errorf("%w; did you mean %q?", err, suggestion)
+		}
+		return err
+	}
+	l.subsystems[target] = level
+	return nil
+}
+
+func parseLevel(s string) (slog.Level, error) {
+	if s == "none" {
+		return slog.LevelError + 1, nil
+	}
+	var level slog.Level
+	err := level.UnmarshalText([]byte(s))
+	return level, err
+}
+
+// lookup returns the override for a device or subsystem.
+// Device overrides take precedence.
+func (l *logLevels) lookup(device, uuid, subsystem string) (slog.Level, bool) {
+	if device != "" {
+		if level, ok := l.devices[strings.ToLower(device)]; ok {
+			return level, true
+		}
+	}
+	if uuid != "" {
+		if level, ok := l.devices[normalizeUUID(uuid)]; ok {
+			return level, true
+		}
+	}
+	if subsystem != "" {
+		if level, ok := l.subsystems[subsystem]; ok {
+			return level, true
+		}
+	}
+	return 0, false
+}
+
+// levelHandler filters records by the current log levels.
+// The wrapped handler should accept every level.
+type levelHandler struct {
+	next slog.Handler
+	base func() slog.Level
+
+	device    string
+	uuid      string
+	subsystem string
+}
+
+func newLevelHandler(next slog.Handler, base func() slog.Level) *levelHandler {
+	return &levelHandler{next: next, base: base}
+}
+
+func globalLogLevel() slog.Level {
+	return currentLogLevels.Load().level
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	minLevel, ok := currentLogLevels.Load().lookup(h.device, h.uuid, h.subsystem)
+	if !ok {
+		minLevel = h.base()
+	}
+	return level >= minLevel && h.next.Enabled(ctx, level)
+}
+
+func (h *levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.next.Handle(ctx, r)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handler := *h
+	filtered := make([]slog.Attr, 0, len(attrs))
+	for _, attr := range attrs {
+		switch attr.Key {
+		case LogKeySubsystem:
+			handler.subsystem = attr.Value.String()
+			continue
+		case LogKeyDeviceUUID:
+			handler.uuid = attr.Value.String()
+			continue
+		case "device":
+			handler.device = attr.Value.String()
+		}
+		filtered = append(filtered, attr)
+	}
+	if len(filtered) != 0 {
+		handler.next = h.next.WithAttrs(filtered)
+	}
+	return &handler
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	handler := *h
+	handler.next = h.next.WithGroup(name)
+	return &handler
+}
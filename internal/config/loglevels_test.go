@@ -0,0 +1,142 @@
+package config
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setLogLevels(t *testing.T, levels *logLevels) {
+	prev := currentLogLevels.Load()
+	currentLogLevels.Store(levels)
+	t.Cleanup(func() {
+		currentLogLevels.Store(prev)
+	})
+}
+
+func TestLogLevels_add(t *testing.T) {
+	tests := []struct {
+		name       string
+		rule       string
+		device     string
+		uuid       string
+		subsystem  string
+		want       slog.Level
+		wantErr    require.ErrorAssertionFunc
+		wantErrMsg string
+	}{
+		{"subsystem", "sponsorblock=debug", "", "", SubsystemSponsorBlock, slog.LevelDebug, require.NoError, ""},
+		{"subsystem case", " Cast = warn ", "", "", SubsystemCast, slog.LevelWarn, require.NoError, ""},
+		{"device name", "device:Living Room=debug", "living room", "", "", slog.LevelDebug, require.NoError, ""},
+		{"device uuid", "device:0123-4567-89AB=error", "", "012345-6789ab", "", slog.LevelError, require.NoError, ""},
+		{"none", "discovery=none", "", "", SubsystemDiscovery, slog.LevelError + 1, require.NoError, ""},
+		{"missing level", "discovery", "", "", "", 0, require.Error, "expected target=level"},
+		{"invalid level", "discovery=loud", "", "", "", 0, require.Error, "unknown name"},
+		{"empty device", "device:=debug", "", "", "", 0, require.Error, "empty device"},
+		{"unknown subsystem", "sponsorblok=debug", "", "", "", 0, require.Error, `did you mean "sponsorblock"?`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			levels := newLogLevels(slog.LevelInfo)
+			err := levels.add(tt.rule)
+			tt.wantErr(t, err)
+			if err != nil {
+				require.ErrorIs(t, err, ErrInvalidLogLevelRule)
+				assert.Contains(t, err.Error(), tt.wantErrMsg)
+				return
+			}
+
+			got, ok := levels.lookup(tt.device, tt.uuid, tt.subsystem)
+			require.True(t, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLevelHandler(t *testing.T) {
+	levels := newLogLevels(slog.LevelInfo)
+	require.NoError(t, levels.add("tick=warn"))
+	require.NoError(t, levels.add("device:TV=debug"))
+	setLogLevels(t, levels)
+
+	var buf bytes.Buffer
+	logger := slog.New(newLevelHandler(
+		slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}),
+		globalLogLevel,
+	))
+
+	logger.Debug("global debug")
+	logger.Info("global info")
+	tick := logger.With("device", "Speaker", LogKeyDeviceUUID, "abc", LogKeySubsystem, SubsystemTick)
+	tick.Info("tick info")
+	tick.Warn("tick warn")
+	tv := logger.With("device", "TV", LogKeySubsystem, SubsystemTick)
+	tv.Debug("tv debug")
+	tv.With(LogKeySubsystem, SubsystemCast).Debug("tv cast debug")
+
+	out := buf.String()
+	assert.NotContains(t, out, "global debug")
+	assert.Contains(t, out, "global info")
+	assert.NotContains(t, out, "tick info")
+	assert.Contains(t, out, "tick warn")
+	assert.Contains(t, out, "tv debug")
+	assert.Contains(t, out, "tv cast debug")
+	assert.Contains(t, out, "device=Speaker")
+	assert.NotContains(t, out, LogKeySubsystem+"=")
+	assert.NotContains(t, out, LogKeyDeviceUUID+"=")
+
+	buf.Reset()
+	levels = newLogLevels(slog.LevelInfo)
+	currentLogLevels.Store(levels)
+	tick.Info("tick info after reload")
+	tv.Debug("tv debug after reload")
+	assert.Contains(t, buf.String(), "tick info after reload")
+	assert.NotContains(t, buf.String(), "tv debug after reload")
+}
+
+func TestLoad_LogLevelsInvalid(t *testing.T) {
+	setupConfigDirs(t)
+	setLogLevels(t, newLogLevels(slog.LevelInfo))
+	t.Setenv("CSS_LOG_LEVELS", "youtube=debug,tik=debug")
+
+	cmd := &cobra.Command{}
+	RegisterFlags(cmd)
+	require.NoError(t, cmd.ParseFlags(nil))
+
+	_, err := Load(cmd)
+	require.ErrorIs(t, err, ErrInvalidLogLevelRule)
+	assert.Contains(t, err.Error(), "log-levels (from env)")
+	assert.Contains(t, err.Error(), `did you mean "tick"?`)
+}
+
+func TestReload(t *testing.T) {
+	setupConfigDirs(t)
+	setLogLevels(t, newLogLevels(slog.LevelInfo))
+
+	cmd := &cobra.Command{}
+	RegisterFlags(cmd)
+	require.NoError(t, cmd.ParseFlags(nil))
+
+	t.Setenv("CSS_LOG_LEVELS", "device:TV=debug")
+	require.NoError(t, Reload(cmd))
+	level, ok := currentLogLevels.Load().lookup("tv", "", SubsystemTick)
+	require.True(t, ok)
+	assert.Equal(t, slog.LevelDebug, level)
+
+	t.Setenv("CSS_LOG_LEVELS", "device:TV=loud")
+	require.Error(t, Reload(cmd))
+	level, ok = currentLogLevels.Load().lookup("tv", "", SubsystemTick)
+	require.True(t, ok)
+	assert.Equal(t, slog.LevelDebug, level, "failed reload should keep the current levels")
+
+	t.Setenv("CSS_LOG_LEVEL", "warn")
+	t.Setenv("CSS_LOG_LEVELS", "")
+	require.NoError(t, Reload(cmd))
+	_, ok = currentLogLevels.Load().lookup("tv", "", SubsystemTick)
+	assert.False(t, ok)
+	assert.Equal(t, slog.LevelWarn, globalLogLevel())
+}
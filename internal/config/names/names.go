@@ -2,22 +2,55 @@ package names
 
 const (
 	FlagConfig    = "config"
+	FlagTUI       = "tui"
 	FlagLogLevel  = "log-level"
 	FlagLogFormat = "log-format"
+	FlagLogSinks  = "log-sinks"
+	FlagLogLevels = "log-levels"
 
 	FlagDevices               = "devices"
+	FlagIncludeDevices        = "include-devices"
+	FlagExcludeDevices        = "exclude-devices"
 	FlagDiscoverInterval      = "discover-interval"
 	FlagPausedInterval        = "paused-interval"
 	FlagPlayingInterval       = "playing-interval"
 	FlagSkipDelay             = "skip-delay"
 	FlagIgnoreSegmentDuration = "ignore-segment-duration"
 
-	FlagNetworkInterface = "network-interface"
+	FlagNetworkInterface        = "network-interface"
+	FlagExcludeNetworkInterface = "exclude-network-interface"
 
 	FlagSkipSponsors = "skip-sponsors"
 	FlagCategories   = "categories"
 	FlagActionTypes  = "action-types"
 
-	FlagYouTubeAPIKey = "youtube-api-key"
-	FlagMuteAds       = "mute-ads"
+	FlagSponsorBlockURL        = "sponsorblock-url"
+	FlagSponsorBlockLookup     = "sponsorblock-lookup"
+	FlagSponsorBlockHashPrefix = "sponsorblock-hash-prefix"
+	FlagSponsorBlockDBFile     = "sponsorblock-db-file"
+	FlagSponsorBlockDBFallback = "sponsorblock-db-fallback"
+	FlagSponsorBlockUserID     = "sponsorblock-user-id"
+	FlagSponsorBlockUserIDFile = "sponsorblock-user-id-file"
+	FlagSegmentHistoryFile     = "segment-history-file"
+	FlagDownvotePrompt         = "downvote-prompt"
+	FlagReportSkips            = "report-skips"
+
+	FlagAudioDevices    = "audio-devices"
+	FlagAudioCategories = "audio-categories"
+
+	FlagYouTubeAPIKey     = "youtube-api-key"
+	FlagYouTubeAPIKeyFile = "youtube-api-key-file"
+	FlagYouTubeAPIQuota   = "youtube-api-quota"
+	FlagMuteAds           = "mute-ads"
+	FlagMuteStateFile     = "mute-state-file"
+	FlagVideoIDBackends   = "video-id-backends"
+
+	FlagVideoIDCacheFile        = "video-id-cache-file"
+	FlagVideoIDCacheSize        = "video-id-cache-size"
+	FlagVideoIDCacheTTL         = "video-id-cache-ttl"
+	FlagVideoIDCacheNegativeTTL = "video-id-cache-negative-ttl"
+
+	FlagControlListen = "control-listen"
+
+	FlagOTLPEndpoint = "otlp-endpoint"
 )
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+)
+
+var ErrNoNetworkInterfaces = errors.New("no usable network interfaces")
+
+func resolveNetworkInterfaces(include, exclude []string) ([]*net.Interface, error) {
+	include = trimNames(include)
+	exclude = trimNames(exclude)
+
+	var candidates []*net.Interface
+	if len(include) != 0 {
+		// Use only the requested interfaces
+		candidates = make([]*net.Interface, 0, len(include))
+		for _, name := range include {
+			iface, err := net.InterfaceByName(name)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %q", err, name)
+			}
+			candidates = append(candidates, iface)
+		}
+	} else {
+		// Use every interface that can receive multicast dns responses
+		interfaces, err := net.Interfaces()
+		if err != nil {
+			return nil, err
+		}
+
+		candidates = make([]*net.Interface, 0, len(interfaces))
+		for _, iface := range interfaces {
+			if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagMulticast == 0 || iface.Flags&net.FlagLoopback != 0 {
+				continue
+			}
+			candidates = append(candidates, &iface)
+		}
+	}
+
+	result := make([]*net.Interface, 0, len(candidates))
+	for _, iface := range candidates {
+		if slices.Contains(exclude, iface.Name) ||
+			slices.ContainsFunc(result, func(v *net.Interface) bool { return v.Index == iface.Index }) {
+			continue
+		}
+		result = append(result, iface)
+	}
+
+	if len(result) == 0 {
+		return nil, ErrNoNetworkInterfaces
+	}
+	return result, nil
+}
+
+func trimNames(names []string) []string {
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			result = append(result, name)
+		}
+	}
+	return result
+}
@@ -1,10 +1,12 @@
 package config
 
 import (
+	"net"
 	"testing"
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_completeNetworkInterface(t *testing.T) {
@@ -12,3 +14,41 @@ func Test_completeNetworkInterface(t *testing.T) {
 	assert.NotEqual(t, cobra.ShellCompDirectiveError, directive)
 	assert.NotEmpty(t, completions)
 }
+
+func Test_resolveNetworkInterfaces(t *testing.T) {
+	interfaces, err := net.Interfaces()
+	require.NoError(t, err)
+	require.NotEmpty(t, interfaces)
+	first := interfaces[0].Name
+
+	t.Run("include", func(t *testing.T) {
+		got, err := resolveNetworkInterfaces([]string{first, " " + first + " "}, nil)
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.Equal(t, first, got[0].Name)
+	})
+
+	t.Run("include and exclude", func(t *testing.T) {
+		got, err := resolveNetworkInterfaces([]string{first}, []string{first})
+		require.ErrorIs(t, err, ErrNoNetworkInterfaces)
+		assert.Nil(t, got)
+	})
+
+	t.Run("exclude", func(t *testing.T) {
+		got, err := resolveNetworkInterfaces(nil, []string{first})
+		if err != nil {
+			require.ErrorIs(t, err, ErrNoNetworkInterfaces)
+			return
+		}
+		for _, iface := range got {
+			assert.NotEqual(t, first, iface.Name)
+			assert.NotZero(t, iface.Flags&net.FlagMulticast)
+			assert.Zero(t, iface.Flags&net.FlagLoopback)
+		}
+	})
+
+	t.Run("unknown interface", func(t *testing.T) {
+		_, err := resolveNetworkInterfaces([]string{"css-does-not-exist"}, nil)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+const (
+	configDirName       = "castsponsorskip"
+	legacyConfigDirName = "sponsorblockcast"
+	dropInDirName       = "conf.d"
+)
+
+//nolint:gochecknoglobals
+var (
+	systemConfigDir = "/etc"
+	configFileNames = []string{"config.yaml", "config.yml"}
+)
+
+func userConfigDir() (string, error) {
+	if xdgConfigDir, err := os.UserConfigDir(); err == nil {
+		return xdgConfigDir, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config"), nil
+}
+
+// UserConfigPath returns the path where the user config file is written.
+func UserConfigPath() (string, error) {
+	configDir, err := userConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, configDirName, configFileNames[0]), nil
+}
+
+// UserCachePath returns the path of a file in the user cache directory.
+func UserCachePath(name string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, configDirName, name), nil
+}
+
+// MuteStatePath returns the path of the file that tracks devices muted by CastSponsorSkip.
+func (c *Config) MuteStatePath() (string, error) {
+	if c.MuteStateFile != "" {
+		return c.MuteStateFile, nil
+	}
+	return UserCachePath("mute-state.json")
+}
+
+// ConfigPaths returns the config files that exist, from lowest to highest precedence:
+// the system config, its conf.d drop-ins in lexical order, then the user config.
+// The legacy sponsorblockcast directories are used when the newer ones have no config file.
+// If userPath is set, it replaces the user config.
+func ConfigPaths(userPath string) ([]string, error) {
+	var paths []string
+
+	systemPath, err := findConfigFile(
+		filepath.Join(systemConfigDir, configDirName),
+		filepath.Join(systemConfigDir, legacyConfigDirName),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if systemPath != "" {
+		paths = append(paths, systemPath)
+	}
+
+	dropIns, err := findDropIns(filepath.Join(systemConfigDir, configDirName, dropInDirName))
+	if err != nil {
+		return nil, err
+	}
+	paths = append(paths, dropIns...)
+
+	if userPath == "" {
+		configDir, err := userConfigDir()
+		if err != nil {
+			return nil, err
+		}
+
+		if userPath, err = findConfigFile(
+			filepath.Join(configDir, configDirName),
+			filepath.Join(configDir, legacyConfigDirName),
+		); err != nil {
+			return nil, err
+		}
+	}
+	if userPath != "" {
+		paths = append(paths, userPath)
+	}
+
+	return paths, nil
+}
+
+// findConfigFile returns the first config file found in dirs.
+func findConfigFile(dirs ...string) (string, error) {
+	for _, dir := range dirs {
+		for _, name := range configFileNames {
+			path := filepath.Join(dir, name)
+			if _, err := os.Stat(path); err == nil {
+				return path, nil
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return "", err
+			}
+		}
+	}
+	return "", nil
+}
+
+func findDropIns(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		switch filepath.Ext(entry.Name()) {
+		case ".yaml", ".yml":
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+	}
+	slices.Sort(paths)
+	return paths, nil
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupConfigDirs(t *testing.T) (string, string) {
+	systemDir := t.TempDir()
+	userDir := t.TempDir()
+
+	prev := systemConfigDir
+	systemConfigDir = systemDir
+	t.Cleanup(func() { systemConfigDir = prev })
+	t.Setenv("XDG_CONFIG_HOME", userDir)
+	return systemDir, userDir
+}
+
+func writeConfig(t *testing.T, path, contents string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+}
+
+func TestConfigPaths(t *testing.T) {
+	t.Run("none", func(t *testing.T) {
+		setupConfigDirs(t)
+		paths, err := ConfigPaths("")
+		require.NoError(t, err)
+		assert.Empty(t, paths)
+	})
+
+	t.Run("layers", func(t *testing.T) {
+		systemDir, userDir := setupConfigDirs(t)
+		system := filepath.Join(systemDir, "castsponsorskip", "config.yaml")
+		dropInA := filepath.Join(systemDir, "castsponsorskip", "conf.d", "10-a.yaml")
+		dropInB := filepath.Join(systemDir, "castsponsorskip", "conf.d", "20-b.yml")
+		user := filepath.Join(userDir, "castsponsorskip", "config.yml")
+		for _, path := range []string{dropInB, system, user, dropInA} {
+			writeConfig(t, path, "")
+		}
+		writeConfig(t, filepath.Join(systemDir, "castsponsorskip", "conf.d", "README"), "")
+
+		paths, err := ConfigPaths("")
+		require.NoError(t, err)
+		assert.Equal(t, []string{system, dropInA, dropInB, user}, paths)
+	})
+
+	t.Run("legacy", func(t *testing.T) {
+		systemDir, userDir := setupConfigDirs(t)
+		system := filepath.Join(systemDir, "sponsorblockcast", "config.yaml")
+		user := filepath.Join(userDir, "sponsorblockcast", "config.yaml")
+		writeConfig(t, system, "")
+		writeConfig(t, user, "")
+
+		paths, err := ConfigPaths("")
+		require.NoError(t, err)
+		assert.Equal(t, []string{system, user}, paths)
+	})
+
+	t.Run("legacy ignored", func(t *testing.T) {
+		_, userDir := setupConfigDirs(t)
+		user := filepath.Join(userDir, "castsponsorskip", "config.yaml")
+		writeConfig(t, user, "")
+		writeConfig(t, filepath.Join(userDir, "sponsorblockcast", "config.yaml"), "")
+
+		paths, err := ConfigPaths("")
+		require.NoError(t, err)
+		assert.Equal(t, []string{user}, paths)
+	})
+
+	t.Run("explicit", func(t *testing.T) {
+		systemDir, userDir := setupConfigDirs(t)
+		system := filepath.Join(systemDir, "castsponsorskip", "config.yaml")
+		writeConfig(t, system, "")
+		writeConfig(t, filepath.Join(userDir, "castsponsorskip", "config.yaml"), "")
+
+		paths, err := ConfigPaths("custom.yaml")
+		require.NoError(t, err)
+		assert.Equal(t, []string{system, "custom.yaml"}, paths)
+	})
+}
+
+func TestLoad_Layers(t *testing.T) {
+	systemDir, userDir := setupConfigDirs(t)
+	system := filepath.Join(systemDir, "castsponsorskip", "config.yaml")
+	dropIn := filepath.Join(systemDir, "castsponsorskip", "conf.d", "50-site.yaml")
+	user := filepath.Join(userDir, "castsponsorskip", "config.yaml")
+	writeConfig(t, system, "skip-delay: 1s\npaused-interval: 2m\nplaying-interval: 2s\n")
+	writeConfig(t, dropIn, "paused-interval: 3m\nplaying-interval: 3s\n")
+	writeConfig(t, user, "playing-interval: 4s\n")
+
+	cmd := &cobra.Command{}
+	RegisterFlags(cmd)
+	require.NoError(t, cmd.ParseFlags(nil))
+
+	conf, err := Load(cmd)
+	require.NoError(t, err)
+	assert.Equal(t, []string{system, dropIn, user}, conf.ConfigFiles)
+	assert.Equal(t, "1s", conf.SkipDelay.String())
+	assert.Equal(t, "3m0s", conf.PausedInterval.String())
+	assert.Equal(t, "4s", conf.PlayingInterval.String())
+	assert.Equal(t, FileSource(system), conf.Sources["skip-delay"])
+	assert.Equal(t, FileSource(dropIn), conf.Sources["paused-interval"])
+	assert.Equal(t, FileSource(user), conf.Sources["playing-interval"])
+}
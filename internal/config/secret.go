@@ -0,0 +1,68 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/knadh/koanf/v2"
+)
+
+const secretFileSuffix = "-file"
+
+func SecretFileSource(path string) Source {
+	return Source("secret file " + path)
+}
+
+// SecretKeys returns the keys of config fields tagged as secrets.
+// Each secret can also be read from a file set by the key with a "-file" suffix.
+func SecretKeys() []string {
+	t := reflect.TypeFor[Config]()
+
+	var keys []string
+	for i := range t.NumField() {
+		if t.Field(i).Tag.Get("secret") == "true" {
+			keys = append(keys, t.Field(i).Tag.Get("yaml"))
+		}
+	}
+	return keys
+}
+
+// loadSecretFiles replaces secrets with the contents of their files.
+// A secret set directly by a later layer takes precedence over a file set by an earlier one.
+func (c *Config) loadSecretFiles(k *koanf.Koanf, problems *ValidationError) {
+	for _, key := range SecretKeys() {
+		fileKey := key + secretFileSuffix
+		path := k.String(fileKey)
+		if path == "" || sourceRank(c.Sources[key]) > sourceRank(c.Sources[fileKey]) {
+			continue
+		}
+
+		b, err := os.ReadFile(path)
+		if err != nil {
+			problems.add(c, fileKey, err)
+			continue
+		}
+
+		if err := k.Set(key, strings.TrimSpace(string(b))); err != nil {
+			problems.add(c, fileKey, err)
+			continue
+		}
+		c.Sources[key] = SecretFileSource(path)
+	}
+}
+
+func sourceRank(source Source) int {
+	switch {
+	case source == SourceFlag:
+		return 4
+	case source == SourceEnv:
+		return 3
+	case source == SourceSponsorBlockCast:
+		return 2
+	case strings.HasPrefix(string(source), string(FileSource(""))):
+		return 1
+	default:
+		return 0
+	}
+}
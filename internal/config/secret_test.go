@@ -0,0 +1,85 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gabe565.com/castsponsorskip/internal/config/names"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretKeys(t *testing.T) {
+	assert.Equal(t, []string{names.FlagSponsorBlockUserID, names.FlagYouTubeAPIKey}, SecretKeys())
+}
+
+func TestLoad_SecretFile(t *testing.T) {
+	setupConfigDirs(t)
+	path := filepath.Join(t.TempDir(), "youtube-api-key")
+	require.NoError(t, os.WriteFile(path, []byte("secret\n"), 0o600))
+
+	tests := []struct {
+		name       string
+		env        map[string]string
+		args       []string
+		want       string
+		wantSource Source
+	}{
+		{
+			name:       "env",
+			env:        map[string]string{"CSS_YOUTUBE_API_KEY_FILE": path},
+			want:       "secret",
+			wantSource: SecretFileSource(path),
+		},
+		{
+			name:       "flag",
+			args:       []string{"--youtube-api-key-file", path},
+			want:       "secret",
+			wantSource: SecretFileSource(path),
+		},
+		{
+			name:       "file overrides earlier layer",
+			env:        map[string]string{"CSS_YOUTUBE_API_KEY": "plain", "CSS_YOUTUBE_API_KEY_FILE": path},
+			want:       "secret",
+			wantSource: SecretFileSource(path),
+		},
+		{
+			name:       "later layer overrides file",
+			env:        map[string]string{"CSS_YOUTUBE_API_KEY_FILE": path},
+			args:       []string{"--youtube-api-key", "plain"},
+			want:       "plain",
+			wantSource: SourceFlag,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cmd := &cobra.Command{}
+			RegisterFlags(cmd)
+			require.NoError(t, cmd.ParseFlags(tt.args))
+
+			conf, err := Load(cmd)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, conf.YouTubeAPIKey)
+			assert.Equal(t, tt.wantSource, conf.Sources[names.FlagYouTubeAPIKey])
+		})
+	}
+}
+
+func TestLoad_SecretFileMissing(t *testing.T) {
+	setupConfigDirs(t)
+	t.Setenv("CSS_YOUTUBE_API_KEY_FILE", filepath.Join(t.TempDir(), "missing"))
+
+	cmd := &cobra.Command{}
+	RegisterFlags(cmd)
+	require.NoError(t, cmd.ParseFlags(nil))
+
+	_, err := Load(cmd)
+	require.ErrorIs(t, err, os.ErrNotExist)
+	assert.Contains(t, err.Error(), "youtube-api-key-file (from env)")
+}
@@ -0,0 +1,29 @@
+package config
+
+import "github.com/knadh/koanf/v2"
+
+type Source string
+
+const (
+	SourceDefault          Source = "default"
+	SourceSponsorBlockCast Source = "sponsorblockcast env"
+	SourceEnv              Source = "env"
+	SourceFlag             Source = "flag"
+)
+
+func FileSource(path string) Source {
+	return Source("file " + path)
+}
+
+// loadLayer loads a provider into k and records which keys it supplied.
+func (c *Config) loadLayer(k *koanf.Koanf, source Source, p koanf.Provider, parser koanf.Parser) error {
+	layer := koanf.New(".")
+	if err := layer.Load(p, parser); err != nil {
+		return err
+	}
+
+	for _, key := range layer.Keys() {
+		c.Sources[key] = source
+	}
+	return k.Merge(layer)
+}
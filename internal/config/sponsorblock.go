@@ -0,0 +1,65 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+var (
+	ErrInvalidSponsorBlockURL = errors.New("invalid SponsorBlock URL")
+	ErrUnknownLookup          = errors.New("unknown SponsorBlock lookup mode")
+	ErrInvalidUserID          = errors.New("invalid SponsorBlock user ID")
+)
+
+const DefaultSponsorBlockURL = "https://sponsor.ajay.app"
+
+const (
+	// LookupHash requests every video whose ID hash starts with a prefix, so the server can't tell which video is playing.
+	LookupHash = "hash"
+	// LookupDirect requests a single video by ID. Useful for self-hosted servers where privacy doesn't matter.
+	LookupDirect = "direct"
+	// LookupLocal reads segments from a database imported with the import-db command.
+	LookupLocal = "local"
+)
+
+//nolint:gochecknoglobals
+var Lookups = []string{LookupHash, LookupDirect, LookupLocal}
+
+// MinHashPrefix and MaxHashPrefix are the hash prefix lengths accepted by the SponsorBlock server.
+const (
+	MinHashPrefix = 3
+	MaxHashPrefix = 32
+)
+
+// MinUserIDLength is the shortest private user ID accepted by the SponsorBlock server.
+const MinUserIDLength = 30
+
+// ParseSponsorBlockURL parses the base URL of a SponsorBlock server.
+func ParseSponsorBlockURL(s string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(s))
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("%w: %q: %w", ErrInvalidSponsorBlockURL, s, err)
+	case u.Scheme != "http" && u.Scheme != "https", u.Host == "":
+		return nil, fmt.Errorf("%w: %q: expected https://host", ErrInvalidSponsorBlockURL, s)
+	}
+	return u, nil
+}
+
+// SponsorBlockDBPath returns the path of the imported SponsorBlock database.
+func (c *Config) SponsorBlockDBPath() (string, error) {
+	if c.SponsorBlockDBFile != "" {
+		return c.SponsorBlockDBFile, nil
+	}
+	return UserCachePath("sponsorblock.db")
+}
+
+// SegmentHistoryPath returns the path of the skipped segment history.
+func (c *Config) SegmentHistoryPath() (string, error) {
+	if c.SegmentHistoryFile != "" {
+		return c.SegmentHistoryFile, nil
+	}
+	return UserCachePath("segment-history.json")
+}
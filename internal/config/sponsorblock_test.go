@@ -5,6 +5,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_completeCategories(t *testing.T) {
@@ -12,3 +13,61 @@ func Test_completeCategories(t *testing.T) {
 	assert.NotEqual(t, cobra.ShellCompDirectiveError, directive)
 	assert.NotEmpty(t, completions)
 }
+
+func TestLoad_SponsorBlock(t *testing.T) {
+	setupConfigDirs(t)
+
+	cmd := &cobra.Command{}
+	RegisterFlags(cmd)
+	require.NoError(t, cmd.ParseFlags([]string{
+		"--sponsorblock-url=ftp://sponsor.example.com",
+		"--sponsorblock-lookup=dirct",
+		"--sponsorblock-hash-prefix=33",
+		"--sponsorblock-user-id=short",
+	}))
+
+	_, err := Load(cmd)
+	require.ErrorIs(t, err, ErrInvalidSponsorBlockURL)
+	require.ErrorIs(t, err, ErrUnknownLookup)
+	require.ErrorIs(t, err, ErrOutOfRange)
+	require.ErrorIs(t, err, ErrInvalidUserID)
+	assert.Contains(t, err.Error(), `did you mean "direct"?`)
+
+	cmd = &cobra.Command{}
+	RegisterFlags(cmd)
+	require.NoError(t, cmd.ParseFlags([]string{
+		"--sponsorblock-url=http://sponsor.example.com:8080",
+		"--sponsorblock-lookup=direct",
+	}))
+
+	conf, err := Load(cmd)
+	require.NoError(t, err)
+	require.NotNil(t, conf.SponsorBlockServer)
+	assert.Equal(t, "sponsor.example.com:8080", conf.SponsorBlockServer.Host)
+	assert.Equal(t, LookupDirect, conf.SponsorBlockLookup)
+	assert.Equal(t, 4, conf.SponsorBlockHashPrefix)
+}
+
+func TestLoad_SponsorBlockHashPrefix(t *testing.T) {
+	tests := []struct {
+		prefix  string
+		wantErr require.ErrorAssertionFunc
+	}{
+		{"2", require.Error},
+		{"3", require.NoError},
+		{"32", require.NoError},
+		{"33", require.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			setupConfigDirs(t)
+
+			cmd := &cobra.Command{}
+			RegisterFlags(cmd)
+			require.NoError(t, cmd.ParseFlags([]string{"--sponsorblock-hash-prefix=" + tt.prefix}))
+
+			_, err := Load(cmd)
+			tt.wantErr(t, err)
+		})
+	}
+}
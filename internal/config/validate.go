@@ -0,0 +1,224 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"gabe565.com/castsponsorskip/internal/config/names"
+)
+
+var (
+	ErrInvalidConfig     = errors.New("invalid config")
+	ErrUnknownKey        = errors.New("unknown key")
+	ErrUnknownCategory   = errors.New("unknown category")
+	ErrUnknownActionType = errors.New("unknown action type")
+	ErrOutOfRange        = errors.New("out of range")
+)
+
+//nolint:gochecknoglobals
+var (
+	KnownCategories = []string{
+		"sponsor",
+		"selfpromo",
+		"interaction",
+		"intro",
+		"outro",
+		"preview",
+		"hook",
+		"music_offtopic",
+		"filler",
+		"poi_highlight",
+		"exclusive_access",
+		"chapter",
+	}
+
+	KnownActionTypes = []string{
+		"skip",
+		"mute",
+		"full",
+		"poi",
+		"chapter",
+	}
+)
+
+type Problem struct {
+	Key    string
+	Source Source
+	Err    error
+}
+
+func (p Problem) Error() string {
+	if p.Source == "" {
+		return p.Key + ": " + p.Err.Error()
+	}
+	return p.Key + " (from " + string(p.Source) + "): " + p.Err.Error()
+}
+
+func (p Problem) Unwrap() error {
+	return p.Err
+}
+
+type ValidationError []Problem
+
+func (e ValidationError) Error() string {
+	var buf strings.Builder
+	buf.WriteString(ErrInvalidConfig.Error() + ":")
+	for _, p := range e {
+		buf.WriteString("\n  - " + p.Error())
+	}
+	return buf.String()
+}
+
+func (e ValidationError) Unwrap() []error {
+	errs := make([]error, 0, len(e)+1)
+	errs = append(errs, ErrInvalidConfig)
+	for _, p := range e {
+		errs = append(errs, p)
+	}
+	return errs
+}
+
+func (e *ValidationError) add(c *Config, key string, err error) {
+	*e = append(*e, Problem{Key: key, Source: c.Sources[key], Err: err})
+}
+
+// validateKeys reports keys loaded from files or env vars that do not map to a config field.
+func (c *Config) validateKeys(problems *ValidationError) {
+	known := make([]string, 0, len(c.Sources))
+	for _, field := range c.Fields() {
+		known = append(known, field.Key)
+	}
+
+	keys := make([]string, 0, len(c.Sources))
+	for key := range c.Sources {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		if slices.Contains(known, key) {
+			continue
+		}
+
+		name := key
+		if c.Sources[key] == SourceEnv {
+			name = envName(key)
+		}
+		err := fmt.Errorf("%w %q", ErrUnknownKey, name)
+		if suggestion := suggest(key, known); suggestion != "" {
+			if c.Sources[key] == SourceEnv {
+				suggestion = envName(suggestion)
+			}
+			err = fmt.Errorf("%w; did you mean %q?", err, suggestion)
+		}
+		problems.add(c, key, err)
+	}
+}
+
+func (c *Config) validateValues(problems *ValidationError) {
+	for _, v := range []struct {
+		key      string
+		value    time.Duration
+		positive bool
+	}{
+		{names.FlagDiscoverInterval, c.DiscoverInterval, true},
+		{names.FlagPausedInterval, c.PausedInterval, true},
+		{names.FlagPlayingInterval, c.PlayingInterval, true},
+		{names.FlagSkipDelay, c.SkipDelay, false},
+		{names.FlagIgnoreSegmentDuration, c.IgnoreSegmentDuration, false},
+		{names.FlagVideoIDCacheTTL, c.VideoIDCacheTTL, true},
+		{names.FlagVideoIDCacheNegativeTTL, c.VideoIDCacheNegativeTTL, false},
+	} {
+		switch {
+		case v.positive && v.value <= 0:
+			problems.add(c, v.key, fmt.Errorf("%w: %s must be greater than 0", ErrOutOfRange, v.value))
+		case v.value < 0:
+			problems.add(c, v.key, fmt.Errorf("%w: %s must not be negative", ErrOutOfRange, v.value))
+		}
+	}
+
+	if c.YouTubeAPIQuota <= 0 {
+		problems.add(c, names.FlagYouTubeAPIQuota, fmt.Errorf("%w: %d must be greater than 0", ErrOutOfRange, c.YouTubeAPIQuota))
+	}
+
+	if c.SponsorBlockHashPrefix < MinHashPrefix || c.SponsorBlockHashPrefix > MaxHashPrefix {
+		problems.add(c, names.FlagSponsorBlockHashPrefix, fmt.Errorf("%w: %d must be between %d and %d",
+			ErrOutOfRange, c.SponsorBlockHashPrefix, MinHashPrefix, MaxHashPrefix,
+		))
+	}
+
+	if c.SponsorBlockUserID != "" && len(c.SponsorBlockUserID) < MinUserIDLength {
+		problems.add(c, names.FlagSponsorBlockUserID, fmt.Errorf("%w: must be at least %d characters",
+			ErrInvalidUserID, MinUserIDLength,
+		))
+	}
+
+	if c.VideoIDCacheSize < 0 {
+		problems.add(c, names.FlagVideoIDCacheSize, fmt.Errorf("%w: %d must not be negative", ErrOutOfRange, c.VideoIDCacheSize))
+	}
+
+	for _, v := range []struct {
+		key    string
+		values []string
+		known  []string
+		err    error
+	}{
+		{names.FlagCategories, c.Categories, KnownCategories, ErrUnknownCategory},
+		{names.FlagAudioCategories, c.AudioCategories, KnownCategories, ErrUnknownCategory},
+		{names.FlagActionTypes, c.ActionTypes, KnownActionTypes, ErrUnknownActionType},
+		{names.FlagSponsorBlockLookup, []string{c.SponsorBlockLookup}, Lookups, ErrUnknownLookup},
+	} {
+		for _, value := range v.values {
+			if slices.Contains(v.known, value) {
+				continue
+			}
+
+			err := fmt.Errorf("%w %q", v.err, value)
+			if suggestion := suggest(value, v.known); suggestion != "" {
+				err = fmt.Errorf("%w; did you mean %q?", err, suggestion)
+			}
+			problems.add(c, v.key, err)
+		}
+	}
+}
+
+func envName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+// suggest returns the closest candidate to s, or an empty string if none are close enough.
+func suggest(s string, candidates []string) string {
+	s = strings.ToLower(s)
+	var best string
+	bestDist := len(s)/2 + 1
+	for _, candidate := range candidates {
+		if dist := levenshtein(s, candidate); dist < bestDist {
+			best, bestDist = candidate, dist
+		}
+	}
+	return best
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
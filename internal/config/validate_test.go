@@ -0,0 +1,70 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_Validation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("catagories: [sponsor]\nplaying-interval: 0s\n"), 0o600))
+	t.Setenv("CSS_ACTION_TYPES", "skip,mutte")
+
+	cmd := &cobra.Command{}
+	RegisterFlags(cmd)
+	require.NoError(t, cmd.ParseFlags([]string{"--config", path, "--skip-delay=-1s"}))
+
+	_, err := Load(cmd)
+	require.Error(t, err)
+	require.ErrorIs(t, err, ErrInvalidConfig)
+	require.ErrorIs(t, err, ErrUnknownKey)
+	require.ErrorIs(t, err, ErrUnknownActionType)
+	require.ErrorIs(t, err, ErrOutOfRange)
+
+	var validationErr ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Len(t, validationErr, 4)
+
+	msg := err.Error()
+	assert.Contains(t, msg, `catagories (from file `+path+`): unknown key "catagories"; did you mean "categories"?`)
+	assert.Contains(t, msg, `playing-interval (from file `+path+`): out of range: 0s must be greater than 0`)
+	assert.Contains(t, msg, `action-types (from env): unknown action type "mutte"; did you mean "mute"?`)
+	assert.Contains(t, msg, `skip-delay (from flag): out of range: -1s must not be negative`)
+}
+
+func TestLoad_ValidationUnknownEnv(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("CSS_SKIP_DELLAY", "1s")
+
+	cmd := &cobra.Command{}
+	RegisterFlags(cmd)
+	require.NoError(t, cmd.ParseFlags(nil))
+
+	_, err := Load(cmd)
+	require.ErrorIs(t, err, ErrUnknownKey)
+	assert.Contains(t, err.Error(), `unknown key "CSS_SKIP_DELLAY"; did you mean "CSS_SKIP_DELAY"?`)
+}
+
+func Test_suggest(t *testing.T) {
+	tests := []struct {
+		name       string
+		s          string
+		candidates []string
+		want       string
+	}{
+		{"typo", "sponsr", KnownCategories, "sponsor"},
+		{"case", "Intro", KnownCategories, "intro"},
+		{"no match", "something", KnownCategories, ""},
+		{"no candidates", "sponsor", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, suggest(tt.s, tt.candidates))
+		})
+	}
+}
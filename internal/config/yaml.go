@@ -0,0 +1,50 @@
+package config
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/knadh/koanf/parsers/yaml"
+)
+
+type Field struct {
+	Key   string
+	Value any
+}
+
+// Fields returns each config key and its value in declaration order.
+func (c *Config) Fields() []Field {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+
+	fields := make([]Field, 0, t.NumField())
+	for i := range t.NumField() {
+		key := t.Field(i).Tag.Get("yaml")
+		if key == "" || key == "-" {
+			continue
+		}
+
+		var value any
+		switch fv := v.Field(i).Interface().(type) {
+		case time.Duration:
+			value = fv.String()
+		case []string:
+			if fv == nil {
+				fv = []string{}
+			}
+			value = fv
+		default:
+			value = fv
+		}
+		fields = append(fields, Field{Key: key, Value: value})
+	}
+	return fields
+}
+
+func (f Field) Marshal() (string, error) {
+	b, err := yaml.Parser().Marshal(map[string]any{f.Key: f.Value})
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
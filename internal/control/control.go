@@ -0,0 +1,110 @@
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"gabe565.com/castsponsorskip/internal/device"
+	"gabe565.com/castsponsorskip/internal/sponsorblock"
+	"gabe565.com/castsponsorskip/internal/util"
+)
+
+// Handler serves the control API, which lets remotes and home automation act on connected devices.
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/devices", handleDevices)
+	mux.HandleFunc("POST /api/devices/{device}/segment/start", handleSegmentStart)
+	mux.HandleFunc("POST /api/devices/{device}/segment/end", handleSegmentEnd)
+	return mux
+}
+
+// ListenAndServe serves the control API on addr until ctx is canceled.
+func ListenAndServe(ctx context.Context, addr string) error {
+	slog.Info("Serving control API.", "address", addr)
+	return util.ListenAndServe(ctx, addr, Handler())
+}
+
+func handleDevices(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, device.Statuses())
+}
+
+type markResponse struct {
+	VideoID string  `json:"videoID"`
+	Start   float32 `json:"start"`
+}
+
+func handleSegmentStart(w http.ResponseWriter, r *http.Request) {
+	d, err := device.Find(r.PathValue("device"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	videoID, start, err := d.MarkSegmentStart()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, markResponse{VideoID: videoID, Start: start})
+}
+
+func handleSegmentEnd(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	category := query.Get("category")
+	if category == "" {
+		category = "sponsor"
+	}
+	if !slices.Contains(config.KnownCategories, category) {
+		http.Error(w, "unknown category: "+category, http.StatusBadRequest)
+		return
+	}
+	actionType := query.Get("actionType")
+	if actionType == "" {
+		actionType = sponsorblock.ActionTypeSkip
+	}
+	if !slices.Contains(config.KnownActionTypes, actionType) {
+		http.Error(w, "unknown action type: "+actionType, http.StatusBadRequest)
+		return
+	}
+
+	d, err := device.Find(r.PathValue("device"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	segment, err := d.SubmitSegment(r.Context(), category, actionType)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, segment)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, device.ErrDeviceNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, device.ErrNoVideo),
+		errors.Is(err, device.ErrNoSegmentStart),
+		errors.Is(err, device.ErrVideoChanged),
+		errors.Is(err, sponsorblock.ErrNoUserID):
+		status = http.StatusConflict
+	case errors.Is(err, sponsorblock.ErrInvalidSegment):
+		status = http.StatusBadRequest
+	case errors.Is(err, sponsorblock.ErrStatusCode):
+		status = http.StatusBadGateway
+	}
+	http.Error(w, err.Error(), status)
+}
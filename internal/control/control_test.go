@@ -0,0 +1,59 @@
+package control
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"gabe565.com/castsponsorskip/internal/device"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	castdns "github.com/vishen/go-chromecast/dns"
+)
+
+func TestHandler(t *testing.T) {
+	d := device.NewDevice(config.New(), device.Entry{CastEntry: castdns.CastEntry{UUID: "control", DeviceName: "Den TV"}})
+	require.NotNil(t, d)
+	t.Cleanup(func() { _ = d.Close() })
+
+	server := httptest.NewServer(Handler())
+	t.Cleanup(server.Close)
+
+	do := func(method, path string) (*http.Response, string) {
+		req, err := http.NewRequestWithContext(t.Context(), method, server.URL+path, nil)
+		require.NoError(t, err)
+		resp, err := server.Client().Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		var body json.RawMessage
+		if resp.Header.Get("Content-Type") == "application/json" {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		}
+		return resp, string(body)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"devices", http.MethodGet, "/api/devices", http.StatusOK, `"Name":"Den TV"`},
+		{"start unknown device", http.MethodPost, "/api/devices/missing/segment/start", http.StatusNotFound, ""},
+		{"start without video", http.MethodPost, "/api/devices/Den%20TV/segment/start", http.StatusConflict, ""},
+		{"end without start", http.MethodPost, "/api/devices/control/segment/end", http.StatusConflict, ""},
+		{"end unknown category", http.MethodPost, "/api/devices/control/segment/end?category=sponsr", http.StatusBadRequest, ""},
+		{"end unknown action type", http.MethodPost, "/api/devices/control/segment/end?actionType=chapter2", http.StatusBadRequest, ""},
+		{"wrong method", http.MethodGet, "/api/devices/control/segment/start", http.StatusMethodNotAllowed, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := do(tt.method, tt.path)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			assert.Contains(t, body, tt.wantBody)
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package dashboard
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"gabe565.com/castsponsorskip/internal/device"
+	"gabe565.com/castsponsorskip/internal/events"
+	"gabe565.com/castsponsorskip/internal/youtube"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+const (
+	clearScreen = "\x1b[H\x1b[2J"
+	hideCursor  = "\x1b[?25l"
+	showCursor  = "\x1b[?25h"
+
+	maxEvents   = 10
+	maxTitleLen = 40
+)
+
+func Run(ctx context.Context, w io.Writer, interval time.Duration) {
+	_, _ = io.WriteString(w, hideCursor)
+	defer func() {
+		_, _ = io.WriteString(w, showCursor)
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		var quota *youtube.QuotaStatus
+		if status, ok := youtube.Quota(); ok {
+			quota = &status
+		}
+		_, _ = io.WriteString(w, clearScreen+Render(device.Statuses(), events.Recent(maxEvents), quota))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Render formats the dashboard. quota is nil if no YouTube API key is configured.
+func Render(statuses []device.Status, recent []events.Event, quota *youtube.QuotaStatus) string {
+	var buf strings.Builder
+	buf.WriteString("CastSponsorSkip - " + strconv.Itoa(len(statuses)) + " devices - ")
+	if quota != nil {
+		buf.WriteString("YouTube quota " + strconv.Itoa(quota.Remaining) + "/" + strconv.Itoa(quota.Limit) +
+			" (resets " + quota.ResetAt.Local().Format("15:04") + ") - ")
+	}
+	buf.WriteString(time.Now().Format(time.DateTime) + "\n\n")
+
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{
+		"Device", "Connection", "App", "State", "Title", "Channel", "Position", "Next Segment", "Muted",
+	})
+	for _, status := range statuses {
+		var position, next, muted string
+		if status.Duration != 0 {
+			position = formatDuration(status.Position) + " / " + formatDuration(status.Duration)
+		} else if status.Position != 0 {
+			position = formatDuration(status.Position)
+		}
+		if status.HasNext {
+			next = formatDuration(status.NextSegment)
+		}
+		if status.Muted {
+			muted = "yes"
+		}
+
+		t.AppendRow(table.Row{
+			status.Name,
+			status.Connection,
+			status.App,
+			status.State,
+			text.Trim(status.Title, maxTitleLen),
+			status.Channel,
+			position,
+			next,
+			muted,
+		})
+	}
+	buf.WriteString(t.Render())
+
+	buf.WriteString("\n\nRecent events\n")
+	if len(recent) == 0 {
+		buf.WriteString("  None yet\n")
+	}
+	for _, event := range recent {
+		buf.WriteString("  " + event.Time.Format(time.TimeOnly) + "  ")
+		if event.Device != "" {
+			buf.WriteString(event.Device + ": ")
+		}
+		buf.WriteString(event.Message + "\n")
+	}
+
+	return buf.String()
+}
+
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	h := d / time.Hour
+	m := (d % time.Hour) / time.Minute
+	s := (d % time.Minute) / time.Second
+
+	pad := func(v time.Duration) string {
+		if v < 10 {
+			return "0" + strconv.Itoa(int(v))
+		}
+		return strconv.Itoa(int(v))
+	}
+
+	if h != 0 {
+		return strconv.Itoa(int(h)) + ":" + pad(m) + ":" + pad(s)
+	}
+	return strconv.Itoa(int(m)) + ":" + pad(s)
+}
@@ -0,0 +1,64 @@
+package dashboard
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"gabe565.com/castsponsorskip/internal/device"
+	"gabe565.com/castsponsorskip/internal/events"
+	"gabe565.com/castsponsorskip/internal/youtube"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	statuses := []device.Status{{
+		Name:        "Living Room TV",
+		Connection:  device.ConnectionConnected,
+		App:         "YouTube",
+		State:       "PLAYING",
+		Title:       "Never Gonna Give You Up",
+		Channel:     "Rick Astley",
+		Position:    62 * time.Second,
+		Duration:    213 * time.Second,
+		NextSegment: 90 * time.Second,
+		HasNext:     true,
+		Muted:       true,
+	}}
+	recent := []events.Event{{
+		Time:    time.Now(),
+		Device:  "Living Room TV",
+		Message: "Skipped sponsor from 1m0s to 1m30s",
+	}}
+
+	quota := &youtube.QuotaStatus{Remaining: 9800, Limit: 10000, ResetAt: time.Now()}
+	out := Render(statuses, recent, quota)
+	assert.Contains(t, out, "1 devices")
+	assert.Contains(t, out, "YouTube quota 9800/10000")
+	assert.Contains(t, out, "Living Room TV")
+	assert.Contains(t, out, "1:02 / 3:33")
+	assert.Contains(t, out, "1:30")
+	assert.Contains(t, out, "Living Room TV: Skipped sponsor from 1m0s to 1m30s")
+
+	out = Render(nil, nil, nil)
+	assert.Contains(t, out, "None yet")
+	assert.NotContains(t, out, "YouTube quota")
+}
+
+func TestFormatDuration(t *testing.T) {
+	assert.Equal(t, "0:00", formatDuration(0))
+	assert.Equal(t, "1:05", formatDuration(65*time.Second))
+	assert.Equal(t, "1:01:01", formatDuration(time.Hour+61*time.Second))
+}
+
+func TestLogHandler(t *testing.T) {
+	logger := slog.New(NewLogHandler(slog.LevelWarn)).With("device", "Kitchen TV")
+	logger.Info("Ignored.")
+	logger.Warn("Failed to seek to timestamp.", "error", "timeout")
+
+	recent := events.Recent(1)
+	require.Len(t, recent, 1)
+	assert.Equal(t, "Kitchen TV", recent[0].Device)
+	assert.Equal(t, "WARN: Failed to seek to timestamp. timeout", recent[0].Message)
+}
@@ -0,0 +1,53 @@
+package dashboard
+
+import (
+	"context"
+	"log/slog"
+
+	"gabe565.com/castsponsorskip/internal/events"
+)
+
+// LogHandler sends log records to the dashboard's event pane instead of stderr,
+// which would otherwise overwrite the dashboard.
+type LogHandler struct {
+	level  slog.Level
+	device string
+}
+
+func NewLogHandler(level slog.Level) *LogHandler {
+	return &LogHandler{level: level}
+}
+
+func (h *LogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *LogHandler) Handle(_ context.Context, r slog.Record) error {
+	device := h.device
+	msg := r.Message
+	r.Attrs(func(attr slog.Attr) bool {
+		switch attr.Key {
+		case "device":
+			device = attr.Value.String()
+		case "error":
+			msg += " " + attr.Value.String()
+		}
+		return true
+	})
+	events.Add(device, r.Level.String()+": "+msg)
+	return nil
+}
+
+func (h *LogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handler := *h
+	for _, attr := range attrs {
+		if attr.Key == "device" {
+			handler.device = attr.Value.String()
+		}
+	}
+	return &handler
+}
+
+func (h *LogHandler) WithGroup(_ string) slog.Handler {
+	return h
+}
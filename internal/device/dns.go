@@ -4,7 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log/slog"
+	"net"
+	"sync"
 	"time"
 
 	"gabe565.com/castsponsorskip/internal/config"
@@ -13,11 +14,16 @@ import (
 
 var ErrDeviceNotFound = errors.New("device not found")
 
-func DiscoverCastDNSEntryByUUID(ctx context.Context, conf *config.Config, uuid string) (castdns.CastEntry, error) {
+type Entry struct {
+	castdns.CastEntry
+	Interface *net.Interface
+}
+
+func DiscoverCastDNSEntryByUUID(ctx context.Context, iface *net.Interface, uuid string) (castdns.CastEntry, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	entries, err := castdns.DiscoverCastDNSEntries(ctx, conf.NetworkInterface)
+	entries, err := castdns.DiscoverCastDNSEntries(ctx, iface)
 	if err != nil {
 		return castdns.CastEntry{}, err
 	}
@@ -34,11 +40,11 @@ func DiscoverCastDNSEntryByUUID(ctx context.Context, conf *config.Config, uuid s
 	}
 }
 
-func DiscoverCastDNSEntries(ctx context.Context, conf *config.Config, ch chan castdns.CastEntry) error {
+func DiscoverCastDNSEntries(ctx context.Context, conf *config.Config, iface *net.Interface, ch chan Entry) error {
 	subCtx, cancel := context.WithTimeout(ctx, conf.DiscoverInterval)
 	defer cancel()
 
-	entries, err := castdns.DiscoverCastDNSEntries(subCtx, conf.NetworkInterface)
+	entries, err := castdns.DiscoverCastDNSEntries(subCtx, iface)
 	if err != nil {
 		return err
 	}
@@ -48,40 +54,42 @@ func DiscoverCastDNSEntries(ctx context.Context, conf *config.Config, ch chan ca
 		case <-subCtx.Done():
 			return nil
 		case entry := <-entries:
-			ch <- entry
+			if allowEntry(conf, entry) {
+				ch <- Entry{CastEntry: entry, Interface: iface}
+			}
 		}
 	}
 }
 
-func BeginDiscover(ctx context.Context, conf *config.Config) (<-chan castdns.CastEntry, error) {
-	ch := make(chan castdns.CastEntry)
+func BeginDiscover(ctx context.Context, conf *config.Config) (<-chan Entry, error) {
+	ch := make(chan Entry)
 
 	go func() {
 		defer close(ch)
 
 		if len(conf.DeviceAddrs) == 0 {
-			if conf.NetworkInterface != nil {
-				slog.Info("Searching for devices...", "interface", conf.NetworkInterfaceName)
-			} else {
-				slog.Info("Searching for devices...")
+			if len(conf.NetworkInterfaces) == 0 {
+				config.Logger(config.SubsystemDiscovery).Info("Searching for devices...")
+				discoverLoop(ctx, conf, nil, ch)
+				return
 			}
 
-			for {
-				select {
-				case <-ctx.Done():
-					return
-				default:
-					if err := DiscoverCastDNSEntries(ctx, conf, ch); err != nil {
-						slog.Error("Failed to discover devices.", "error", err.Error())
-						continue
-					}
-				}
+			var group sync.WaitGroup
+			for _, iface := range conf.NetworkInterfaces {
+				config.Logger(config.SubsystemDiscovery).Info("Searching for devices...", "interface", iface.Name)
+				group.Go(func() {
+					discoverLoop(ctx, conf, iface, ch)
+				})
 			}
+			group.Wait()
 		} else {
-			if conf.NetworkInterface != nil {
-				slog.Info("Connecting to configured devices...", "interface", conf.NetworkInterfaceName)
+			// Static devices are reached through the configured interface if there is only one
+			var iface *net.Interface
+			if len(conf.NetworkInterfaces) == 1 {
+				iface = conf.NetworkInterfaces[0]
+				config.Logger(config.SubsystemDiscovery).Info("Connecting to configured devices...", "interface", iface.Name)
 			} else {
-				slog.Info("Connecting to configured devices...")
+				config.Logger(config.SubsystemDiscovery).Info("Connecting to configured devices...")
 			}
 
 			timer := time.NewTimer(0)
@@ -91,8 +99,9 @@ func BeginDiscover(ctx context.Context, conf *config.Config) (<-chan castdns.Cas
 				case <-ctx.Done():
 					return
 				case <-timer.C:
+					// Include and exclude rules only apply to discovered devices
 					for _, castEntry := range conf.DeviceAddrs {
-						ch <- castEntry
+						ch <- Entry{CastEntry: castEntry, Interface: iface}
 					}
 					timer.Reset(conf.DiscoverInterval)
 				}
@@ -102,3 +111,29 @@ func BeginDiscover(ctx context.Context, conf *config.Config) (<-chan castdns.Cas
 
 	return ch, nil
 }
+
+func allowEntry(conf *config.Config, entry castdns.CastEntry) bool {
+	ok, reason := conf.AllowDevice(entry)
+	if !ok {
+		config.Logger(config.SubsystemDiscovery).Debug("Ignoring device.", "device", Entry{CastEntry: entry}.Name(), "reason", reason)
+	}
+	return ok
+}
+
+func discoverLoop(ctx context.Context, conf *config.Config, iface *net.Interface, ch chan Entry) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+			if err := DiscoverCastDNSEntries(ctx, conf, iface, ch); err != nil {
+				if iface != nil {
+					config.Logger(config.SubsystemDiscovery).Error("Failed to discover devices.", "interface", iface.Name, "error", err.Error())
+				} else {
+					config.Logger(config.SubsystemDiscovery).Error("Failed to discover devices.", "error", err.Error())
+				}
+				continue
+			}
+		}
+	}
+}
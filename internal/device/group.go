@@ -0,0 +1,145 @@
+package device
+
+import (
+	"sync"
+)
+
+const CastGroup = "Google Cast Group"
+
+//nolint:gochecknoglobals
+var (
+	knownEntries   = make(map[string]Entry)
+	knownEntriesMu sync.Mutex
+
+	groupSessions   = make(map[string]*Device)
+	groupSessionsMu sync.Mutex
+)
+
+func (e Entry) Name() string {
+	if e.DeviceName != "" {
+		return e.DeviceName
+	}
+	return e.Device
+}
+
+func (e Entry) IsGroup() bool {
+	return e.Device == CastGroup
+}
+
+// rememberEntry records the entry of a watched device, so group leaders can be found by address.
+func rememberEntry(entry Entry) {
+	knownEntriesMu.Lock()
+	defer knownEntriesMu.Unlock()
+	knownEntries[entry.UUID] = entry
+}
+
+// forgetEntry removes a device that is no longer watched. It is remembered again when it is rediscovered.
+func forgetEntry(uuid string) {
+	knownEntriesMu.Lock()
+	defer knownEntriesMu.Unlock()
+	delete(knownEntries, uuid)
+}
+
+// findGroupLeader returns the device hosting a cast group.
+// Groups are advertised by their leader, so the leader shares the group's address.
+func findGroupLeader(group Entry) (Entry, bool) {
+	knownEntriesMu.Lock()
+	defer knownEntriesMu.Unlock()
+
+	addr := group.GetAddr()
+	for _, entry := range knownEntries {
+		if !entry.IsGroup() && entry.GetAddr() == addr {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// groupHasVideoOut reports whether the leader of a cast group supports video.
+// A group discovered before its leader is checked again when it is rediscovered.
+func groupHasVideoOut(group Entry) bool {
+	leader, ok := findGroupLeader(group)
+	if !ok {
+		return false
+	}
+	hasVideoOut, err := HasVideoOut(leader.CastEntry)
+	return err != nil || hasVideoOut
+}
+
+// claimGroupSession marks a receiver session as handled by a cast group.
+// Member devices that report the same session will defer to the group.
+func (d *Device) claimGroupSession(sessionID string) {
+	if sessionID == d.sessionID {
+		return
+	}
+	d.releaseGroupSession()
+	if sessionID == "" {
+		return
+	}
+
+	groupSessionsMu.Lock()
+	groupSessions[sessionID] = d
+	groupSessionsMu.Unlock()
+	d.sessionID = sessionID
+
+	if leader, ok := findGroupLeader(d.entry); ok {
+		d.logger.Info("Watching cast group session.", "leader", leader.Name())
+	} else {
+		d.logger.Info("Watching cast group session.")
+	}
+}
+
+func (d *Device) releaseGroupSession() {
+	if d.sessionID == "" {
+		return
+	}
+
+	groupSessionsMu.Lock()
+	if groupSessions[d.sessionID] == d {
+		delete(groupSessions, d.sessionID)
+	}
+	groupSessionsMu.Unlock()
+	d.sessionID = ""
+}
+
+func groupForSession(sessionID string) *Device {
+	if sessionID == "" {
+		return nil
+	}
+
+	groupSessionsMu.Lock()
+	defer groupSessionsMu.Unlock()
+	return groupSessions[sessionID]
+}
+
+// deferToGroup reports whether a cast group is handling this device's session.
+func (d *Device) deferToGroup(sessionID string) bool {
+	group := groupForSession(sessionID)
+	if group == d.group {
+		return group != nil
+	}
+
+	d.group = group
+	if group == nil {
+		d.logger.Info("Cast group session ended. Watching device directly.")
+		return false
+	}
+
+	d.unmuteSegment()
+	d.segments = nil
+	d.prevSegmentIdx = NoSkippedSegment
+	d.meta.Clear()
+	d.endVideoSession()
+
+	if leader, ok := findGroupLeader(group.entry); ok {
+		d.logger.Info("Device is a member of a playing cast group. Skipping through the group.",
+			"group", group.entry.Name(),
+			"leader", leader.Name(),
+		)
+	} else {
+		d.logger.Info("Device is a member of a playing cast group. Skipping through the group.",
+			"group", group.entry.Name(),
+		)
+	}
+	return true
+}
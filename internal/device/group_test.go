@@ -0,0 +1,107 @@
+package device
+
+import (
+	"log/slog"
+	"net"
+	"testing"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	castdns "github.com/vishen/go-chromecast/dns"
+)
+
+func TestFindGroupLeader(t *testing.T) {
+	t.Cleanup(func() {
+		knownEntries = make(map[string]Entry)
+	})
+
+	leader := Entry{CastEntry: castdns.CastEntry{
+		UUID:       "leader",
+		DeviceName: "Living Room TV",
+		Device:     "Chromecast",
+		AddrV4:     net.IPv4(192, 168, 1, 10),
+		Port:       8009,
+	}}
+	other := Entry{CastEntry: castdns.CastEntry{
+		UUID:       "other",
+		DeviceName: "Kitchen speaker",
+		Device:     "Google Nest Mini",
+		AddrV4:     net.IPv4(192, 168, 1, 11),
+		Port:       8009,
+	}}
+	group := Entry{CastEntry: castdns.CastEntry{
+		UUID:       "group",
+		DeviceName: "Downstairs",
+		Device:     CastGroup,
+		AddrV4:     net.IPv4(192, 168, 1, 10),
+		Port:       32187,
+	}}
+
+	rememberEntry(other)
+	rememberEntry(group)
+	_, ok := findGroupLeader(group)
+	assert.False(t, ok)
+
+	rememberEntry(leader)
+	got, ok := findGroupLeader(group)
+	assert.True(t, ok)
+	assert.Equal(t, leader.UUID, got.UUID)
+}
+
+func TestDevice_CloseForgetsEntry(t *testing.T) {
+	entry := Entry{CastEntry: castdns.CastEntry{UUID: "forget", DeviceName: "Office TV"}}
+	d := NewDevice(config.New(), entry)
+	require.NotNil(t, d)
+
+	knownEntriesMu.Lock()
+	_, ok := knownEntries[entry.UUID]
+	knownEntriesMu.Unlock()
+	assert.True(t, ok)
+
+	require.NoError(t, d.Close())
+	knownEntriesMu.Lock()
+	_, ok = knownEntries[entry.UUID]
+	knownEntriesMu.Unlock()
+	assert.False(t, ok, "closed devices should be forgotten")
+}
+
+func TestNewDevice_IgnoredNotRemembered(t *testing.T) {
+	speaker := Entry{CastEntry: castdns.CastEntry{
+		UUID:       "ignored",
+		DeviceName: "Kitchen speaker",
+		Device:     "Google Nest Mini",
+		InfoFields: map[string]string{"ca": "199172"},
+	}}
+	assert.Nil(t, NewDevice(config.New(), speaker))
+
+	knownEntriesMu.Lock()
+	_, ok := knownEntries[speaker.UUID]
+	knownEntriesMu.Unlock()
+	assert.False(t, ok, "ignored devices should not be remembered")
+}
+
+func TestGroupSessions(t *testing.T) {
+	group := &Device{
+		entry:  Entry{CastEntry: castdns.CastEntry{UUID: "group", Device: CastGroup}},
+		logger: slog.New(slog.DiscardHandler),
+	}
+	member := &Device{
+		entry:          Entry{CastEntry: castdns.CastEntry{UUID: "member", Device: "Chromecast"}},
+		logger:         slog.New(slog.DiscardHandler),
+		mutedSegmentID: NoMutedSegment,
+		prevSegmentIdx: NoSkippedSegment,
+	}
+	t.Cleanup(group.releaseGroupSession)
+
+	assert.False(t, member.deferToGroup("session"))
+
+	group.claimGroupSession("session")
+	assert.Same(t, group, groupForSession("session"))
+	assert.True(t, member.deferToGroup("session"))
+	assert.False(t, member.deferToGroup("other session"))
+
+	group.releaseGroupSession()
+	assert.Nil(t, groupForSession("session"))
+	assert.False(t, member.deferToGroup("session"))
+}
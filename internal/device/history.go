@@ -0,0 +1,52 @@
+package device
+
+import (
+	"strconv"
+	"time"
+
+	"gabe565.com/castsponsorskip/internal/history"
+	"gabe565.com/castsponsorskip/internal/sponsorblock"
+)
+
+// downvotePromptSeekBacks is how often playback must return to a skipped segment before downvoting it is suggested.
+const downvotePromptSeekBacks = 2
+
+// recordHistory queues a segment the device acted on, so it can be voted on later.
+func (d *Device) recordHistory(segment sponsorblock.Segment) {
+	if d.history == nil || segment.UUID == "" {
+		return
+	}
+
+	d.history.Record(history.Entry{
+		Time:      time.Now(),
+		Device:    d.entry.Name(),
+		VideoID:   d.meta.CurrVideoID,
+		UUID:      segment.UUID,
+		Category:  segment.Category,
+		Action:    segment.ActionType,
+		Segment:   segment.Segment,
+		SeekBacks: d.seekBacks[segment.UUID],
+	})
+}
+
+// seekedBack is called when playback returns to a segment that was skipped.
+func (d *Device) seekedBack(segment sponsorblock.Segment) {
+	if d.seekBacks == nil {
+		d.seekBacks = make(map[string]int)
+	}
+	d.seekBacks[segment.UUID]++
+	count := d.seekBacks[segment.UUID]
+
+	d.logger.Debug("Playback returned to skipped segment.", "category", segment.Category, "uuid", segment.UUID, "count", count)
+	d.recordHistory(segment)
+
+	if d.config.DownvotePrompt && count == downvotePromptSeekBacks {
+		command := "castsponsorskip vote --down " + segment.UUID
+		d.logger.Info("Playback keeps returning to a skipped segment. If the segment is wrong, downvote it.",
+			"category", segment.Category,
+			"uuid", segment.UUID,
+			"command", command,
+		)
+		d.event("Returned to skipped " + segment.Category + " " + strconv.Itoa(count) + " times. Downvote with: " + command)
+	}
+}
@@ -0,0 +1,64 @@
+package device
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"gabe565.com/castsponsorskip/internal/events"
+	"gabe565.com/castsponsorskip/internal/history"
+	"gabe565.com/castsponsorskip/internal/sponsorblock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vishen/go-chromecast/cast"
+	castdns "github.com/vishen/go-chromecast/dns"
+)
+
+func TestDevice_seekedBack(t *testing.T) {
+	conf := config.New()
+	conf.SegmentHistoryFile = filepath.Join(t.TempDir(), "segment-history.json")
+	conf.DownvotePrompt = true
+
+	recorder := history.NewRecorder(conf.SegmentHistoryFile)
+	ctx, cancel := context.WithCancel(t.Context())
+	go recorder.Run(ctx)
+
+	d := NewDevice(conf, Entry{CastEntry: castdns.CastEntry{UUID: "seek-back", DeviceName: "Bedroom TV"}}, WithHistory(recorder))
+	require.NotNil(t, d)
+	t.Cleanup(func() { _ = d.Close() })
+
+	segment := sponsorblock.Segment{
+		Segment:    [2]float32{10, 30},
+		UUID:       "annoying",
+		Category:   "sponsor",
+		ActionType: sponsorblock.ActionTypeSkip,
+	}
+	d.meta.CurrVideoID = "dQw4w9WgXcQ"
+	d.segments = []sponsorblock.Segment{segment}
+	// The segment was just skipped
+	d.prevSegmentIdx = 0
+	d.prevSegmentIgnore = time.Now().Add(time.Minute)
+
+	castVol := &cast.Volume{}
+	for _, position := range []float32{15, 16, 40, 12, 13} {
+		d.handleSegments(&cast.Media{CurrentTime: position}, castVol)
+	}
+	assert.Equal(t, 2, d.seekBacks[segment.UUID])
+
+	recent := events.Recent(1)
+	require.Len(t, recent, 1)
+	assert.Equal(t, "Bedroom TV", recent[0].Device)
+	assert.Contains(t, recent[0].Message, "castsponsorskip vote --down annoying")
+
+	// Queued entries are written before the recorder stops
+	cancel()
+	recorder.Wait()
+	entries, err := history.Load(conf.SegmentHistoryFile)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "annoying", entries[0].UUID)
+	assert.Equal(t, "dQw4w9WgXcQ", entries[0].VideoID)
+	assert.Equal(t, 2, entries[0].SeekBacks)
+}
@@ -0,0 +1,145 @@
+package device
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+
+	"gabe565.com/castsponsorskip/internal/util"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// muteState records devices that CastSponsorSkip muted, keyed by UUID.
+// It is saved whenever a device is muted or unmuted, so a device left muted by a crash can be unmuted on the next connection.
+type muteState struct {
+	Devices map[string]mutedDevice `json:"devices"`
+}
+
+type mutedDevice struct {
+	Name    string    `json:"name"`
+	MutedAt time.Time `json:"mutedAt"`
+}
+
+//nolint:gochecknoglobals
+var muteStateMu sync.Mutex
+
+func loadMuteState(path string) (muteState, error) {
+	state := muteState{Devices: make(map[string]mutedDevice)}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return state, nil
+		}
+		return state, err
+	}
+
+	if err := json.Unmarshal(b, &state); err != nil {
+		return muteState{Devices: make(map[string]mutedDevice)}, fmt.Errorf("failed to parse mute state %q: %w", path, err)
+	}
+	if state.Devices == nil {
+		state.Devices = make(map[string]mutedDevice)
+	}
+	return state, nil
+}
+
+// setMuteState marks whether a device is muted by CastSponsorSkip.
+// It returns true if the record changed.
+func setMuteState(path, uuid, name string, muted bool) (bool, error) {
+	muteStateMu.Lock()
+	defer muteStateMu.Unlock()
+
+	// An unreadable file is replaced when muting, so the new mute is still recorded
+	state, err := loadMuteState(path)
+	if err != nil && !muted {
+		return false, err
+	}
+
+	// The file is only written when the state changes, since ads are muted and unmuted repeatedly
+	_, wasMuted := state.Devices[uuid]
+	switch {
+	case muted == wasMuted:
+		return false, nil
+	case muted:
+		state.Devices[uuid] = mutedDevice{Name: name, MutedAt: time.Now()}
+	default:
+		delete(state.Devices, uuid)
+	}
+	return true, saveMuteState(path, state)
+}
+
+// mutedByUs reports whether CastSponsorSkip muted a device and has not unmuted it.
+func mutedByUs(path, uuid string) (bool, error) {
+	muteStateMu.Lock()
+	defer muteStateMu.Unlock()
+
+	state, err := loadMuteState(path)
+	if err != nil {
+		return false, err
+	}
+	_, ok := state.Devices[uuid]
+	return ok, nil
+}
+
+// saveMuteState writes the mute state file atomically. The caller must hold muteStateMu.
+func saveMuteState(path string, state muteState) error {
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return util.WriteFileAtomic(path, b)
+}
+
+// saveMuted records a mute or unmute of the device. It returns true if the record changed.
+func (d *Device) saveMuted(muted bool) bool {
+	path, err := d.config.MuteStatePath()
+	if err != nil {
+		d.logger.Debug("Failed to find mute state path.", "error", err.Error())
+		return false
+	}
+	changed, err := setMuteState(path, d.entry.UUID, d.entry.Name(), muted)
+	if err != nil {
+		d.logger.Warn("Failed to save mute state.", "error", err.Error())
+		return false
+	}
+	return changed
+}
+
+// restoreMute unmutes a device that was left muted, for example if the process was killed during a muted segment or ad.
+// The device is only unmuted if it is still muted and CastSponsorSkip muted it.
+func (d *Device) restoreMute() {
+	if d.mutedSegmentID != NoMutedSegment {
+		return
+	}
+
+	path, err := d.config.MuteStatePath()
+	if err != nil {
+		return
+	}
+	muted, err := mutedByUs(path, d.entry.UUID)
+	if err != nil {
+		d.logger.Warn("Failed to load mute state.", "error", err.Error())
+		return
+	}
+	if !muted {
+		return
+	}
+
+	_, _, castVol := d.app.Status()
+	if castVol == nil || !castVol.Muted {
+		// Unmuted by someone else since
+		d.saveMuted(false)
+		return
+	}
+
+	d.logger.Info("Device was left muted. Unmuting.")
+	if err := d.setMuted(false, attribute.String("reason", "restore")); err != nil {
+		d.logger.Warn("Failed to unmute device.", "error", err.Error())
+		return
+	}
+	d.event("Unmuted after restart")
+}
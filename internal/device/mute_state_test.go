@@ -0,0 +1,184 @@
+package device
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vishen/go-chromecast/cast"
+	castdns "github.com/vishen/go-chromecast/dns"
+)
+
+func TestMuteState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "mute-state.json")
+
+	muted, err := mutedByUs(path, "tv")
+	require.NoError(t, err)
+	assert.False(t, muted)
+
+	// Unmuting a device that was never muted does not write the file
+	changed, err := setMuteState(path, "tv", "Living Room TV", false)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.NoFileExists(t, path)
+
+	changed, err = setMuteState(path, "tv", "Living Room TV", true)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	_, err = setMuteState(path, "speaker", "Kitchen speaker", true)
+	require.NoError(t, err)
+	muted, err = mutedByUs(path, "tv")
+	require.NoError(t, err)
+	assert.True(t, muted)
+
+	// Muting a device that is already recorded does not write the file
+	state, err := loadMuteState(path)
+	require.NoError(t, err)
+	changed, err = setMuteState(path, "tv", "Living Room TV", true)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	unchanged, err := loadMuteState(path)
+	require.NoError(t, err)
+	assert.Equal(t, state, unchanged)
+
+	changed, err = setMuteState(path, "tv", "Living Room TV", false)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	muted, err = mutedByUs(path, "tv")
+	require.NoError(t, err)
+	assert.False(t, muted)
+	muted, err = mutedByUs(path, "speaker")
+	require.NoError(t, err)
+	assert.True(t, muted)
+
+	state, err = loadMuteState(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Kitchen speaker", state.Devices["speaker"].Name)
+}
+
+func TestMuteState_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mute-state.json")
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+
+	_, err := mutedByUs(path, "tv")
+	require.Error(t, err)
+	_, err = setMuteState(path, "tv", "Living Room TV", false)
+	require.Error(t, err)
+
+	// A new mute replaces the unreadable file
+	_, err = setMuteState(path, "tv", "Living Room TV", true)
+	require.NoError(t, err)
+	muted, err := mutedByUs(path, "tv")
+	require.NoError(t, err)
+	assert.True(t, muted)
+}
+
+type fakeApp struct {
+	castApplication
+	muted    bool
+	setMuted []bool
+	// onSetMuted is called before the volume changes. SetMuted fails if it returns an error.
+	onSetMuted func() error
+}
+
+func (a *fakeApp) Status() (*cast.Application, *cast.Media, *cast.Volume) {
+	return nil, nil, &cast.Volume{Muted: a.muted}
+}
+
+func (a *fakeApp) SetMuted(muted bool) error {
+	if a.onSetMuted != nil {
+		if err := a.onSetMuted(); err != nil {
+			return err
+		}
+	}
+	a.muted = muted
+	a.setMuted = append(a.setMuted, muted)
+	return nil
+}
+
+func (a *fakeApp) Close(bool) error { return nil }
+
+func TestDevice_restoreMute(t *testing.T) {
+	tests := []struct {
+		name         string
+		recorded     bool
+		muted        bool
+		wantSetMuted []bool
+	}{
+		{"still muted", true, true, []bool{false}},
+		{"already unmuted", true, false, nil},
+		{"not recorded", false, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := config.New()
+			conf.MuteStateFile = filepath.Join(t.TempDir(), "mute-state.json")
+			if tt.recorded {
+				_, err := setMuteState(conf.MuteStateFile, "restore", "Den TV", true)
+				require.NoError(t, err)
+			}
+
+			d := NewDevice(conf, Entry{CastEntry: castdns.CastEntry{UUID: "restore", DeviceName: "Den TV"}})
+			require.NotNil(t, d)
+			t.Cleanup(func() { _ = d.Close() })
+			app := &fakeApp{muted: tt.muted}
+			d.app = app
+
+			d.restoreMute()
+			assert.Equal(t, tt.wantSetMuted, app.setMuted)
+			assert.Equal(t, tt.muted && !tt.recorded, app.muted)
+
+			muted, err := mutedByUs(conf.MuteStateFile, "restore")
+			require.NoError(t, err)
+			assert.False(t, muted, "the device should not be recorded as muted")
+		})
+	}
+}
+
+func TestDevice_setMuted(t *testing.T) {
+	conf := config.New()
+	conf.MuteStateFile = filepath.Join(t.TempDir(), "mute-state.json")
+	d := NewDevice(conf, Entry{CastEntry: castdns.CastEntry{UUID: "set-muted", DeviceName: "Den TV"}})
+	require.NotNil(t, d)
+	t.Cleanup(func() { _ = d.Close() })
+	app := &fakeApp{}
+	d.app = app
+	recorded := func() bool {
+		muted, err := mutedByUs(conf.MuteStateFile, "set-muted")
+		require.NoError(t, err)
+		return muted
+	}
+
+	// The mute is recorded before it is sent
+	app.onSetMuted = func() error {
+		assert.True(t, recorded())
+		return nil
+	}
+	require.NoError(t, d.setMuted(true))
+	assert.True(t, recorded())
+
+	// A failed unmute keeps the record
+	app.onSetMuted = func() error {
+		assert.True(t, recorded())
+		return errors.New("unreachable")
+	}
+	require.Error(t, d.setMuted(false))
+	assert.True(t, recorded())
+
+	// A failed mute keeps a record that was already saved
+	require.Error(t, d.setMuted(true))
+	assert.True(t, recorded())
+
+	app.onSetMuted = nil
+	require.NoError(t, d.setMuted(false))
+	assert.False(t, recorded())
+
+	// A failed mute removes its record
+	app.onSetMuted = func() error { return errors.New("unreachable") }
+	require.Error(t, d.setMuted(true))
+	assert.False(t, recorded())
+}
@@ -2,6 +2,9 @@ package device
 
 import (
 	"context"
+
+	"gabe565.com/castsponsorskip/internal/history"
+	"gabe565.com/castsponsorskip/internal/sponsorblock"
 )
 
 type Option func(device *Device)
@@ -11,3 +14,17 @@ func WithContext(ctx context.Context) Option {
 		device.ctx, device.cancel = context.WithCancel(ctx) //nolint:gosec
 	}
 }
+
+// WithReporter reports skipped segments to SponsorBlock.
+func WithReporter(r *sponsorblock.Reporter) Option {
+	return func(device *Device) {
+		device.reporter = r
+	}
+}
+
+// WithHistory records skipped and muted segments to the segment history.
+func WithHistory(r *history.Recorder) Option {
+	return func(device *Device) {
+		device.history = r
+	}
+}
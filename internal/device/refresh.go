@@ -0,0 +1,86 @@
+package device
+
+import (
+	"time"
+
+	"gabe565.com/castsponsorskip/internal/sponsorblock"
+	"gabe565.com/castsponsorskip/internal/util"
+)
+
+type refreshedSegments struct {
+	videoID  string
+	segments []sponsorblock.Segment
+}
+
+// requestRefresh asks the tick loop to query the segments of the current video again.
+// It is safe to call from any goroutine.
+func (d *Device) requestRefresh() {
+	select {
+	case d.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// startRefresh queries the segments of the current video in the background.
+// The result is applied by the tick loop, which owns d.segments.
+func (d *Device) startRefresh() {
+	videoID := d.meta.CurrVideoID
+	if videoID == "" {
+		return
+	}
+
+	ctx := d.traceContext()
+	go func() {
+		var segments []sponsorblock.Segment
+		if err := util.Retry(ctx, 10, 500*time.Millisecond, func(_ uint) error {
+			var err error
+			segments, err = sponsorblock.QuerySegments(ctx, d.config, videoID)
+			return err
+		}); err != nil {
+			d.logger.Error("Failed to refresh segments.", "error", err.Error())
+			return
+		}
+
+		select {
+		case d.refreshed <- refreshedSegments{videoID: videoID, segments: segments}:
+		case <-ctx.Done():
+		}
+	}()
+}
+
+// applyRefresh replaces the segments of the current video.
+// Indexes of the muted and previously skipped segments are matched by UUID.
+func (d *Device) applyRefresh(r refreshedSegments) {
+	if r.videoID != d.meta.CurrVideoID {
+		return
+	}
+
+	mutedIdx := NoMutedSegment
+	if d.mutedSegmentID != NoMutedSegment {
+		if mutedIdx = segmentIndex(r.segments, d.segments[d.mutedSegmentID].UUID); mutedIdx == -1 {
+			d.unmuteSegment()
+			mutedIdx = NoMutedSegment
+		}
+	}
+
+	skippedIdx := NoSkippedSegment
+	if d.prevSegmentIdx != NoSkippedSegment && d.prevSegmentIdx < len(d.segments) {
+		if skippedIdx = segmentIndex(r.segments, d.segments[d.prevSegmentIdx].UUID); skippedIdx == -1 {
+			skippedIdx = NoSkippedSegment
+		}
+	}
+
+	d.segments = r.segments
+	d.mutedSegmentID = mutedIdx
+	d.prevSegmentIdx = skippedIdx
+	d.logger.Info("Refreshed segments for video.", "segments", len(d.segments))
+}
+
+func segmentIndex(segments []sponsorblock.Segment, uuid string) int {
+	for i, segment := range segments {
+		if segment.UUID == uuid {
+			return i
+		}
+	}
+	return -1
+}
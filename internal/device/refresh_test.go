@@ -0,0 +1,36 @@
+package device
+
+import (
+	"testing"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"gabe565.com/castsponsorskip/internal/sponsorblock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	castdns "github.com/vishen/go-chromecast/dns"
+)
+
+func TestDevice_applyRefresh(t *testing.T) {
+	d := NewDevice(config.New(), Entry{CastEntry: castdns.CastEntry{UUID: "refresh", DeviceName: "Attic TV"}})
+	require.NotNil(t, d)
+	t.Cleanup(func() { _ = d.Close() })
+
+	a := sponsorblock.Segment{UUID: "a", Segment: [2]float32{10, 20}}
+	b := sponsorblock.Segment{UUID: "b", Segment: [2]float32{30, 40}}
+	c := sponsorblock.Segment{UUID: "c", Segment: [2]float32{5, 8}}
+
+	d.meta.CurrVideoID = "video"
+	d.segments = []sponsorblock.Segment{a, b}
+	d.prevSegmentIdx = 1
+
+	// Results for another video are ignored
+	d.applyRefresh(refreshedSegments{videoID: "other", segments: []sponsorblock.Segment{c}})
+	assert.Equal(t, []sponsorblock.Segment{a, b}, d.segments)
+
+	d.applyRefresh(refreshedSegments{videoID: "video", segments: []sponsorblock.Segment{c, a, b}})
+	assert.Equal(t, []sponsorblock.Segment{c, a, b}, d.segments)
+	assert.Equal(t, 2, d.prevSegmentIdx)
+
+	d.applyRefresh(refreshedSegments{videoID: "video", segments: []sponsorblock.Segment{c}})
+	assert.Equal(t, NoSkippedSegment, d.prevSegmentIdx)
+}
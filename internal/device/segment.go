@@ -0,0 +1,39 @@
+package device
+
+import (
+	"gabe565.com/castsponsorskip/internal/config"
+	"gabe565.com/castsponsorskip/internal/sponsorblock"
+)
+
+const ActionIgnore = "ignore"
+
+type SegmentPlan struct {
+	Action string `json:"action"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// PlanSegment reports what a device would do when playback reaches a segment.
+func PlanSegment(conf *config.Config, segment sponsorblock.Segment) SegmentPlan {
+	switch {
+	case !conf.SkipSponsors:
+		return SegmentPlan{Action: ActionIgnore, Reason: "sponsor skipping is disabled"}
+	case segment.ActionType != sponsorblock.ActionTypeSkip && segment.ActionType != sponsorblock.ActionTypeMute:
+		return SegmentPlan{Action: ActionIgnore, Reason: "unsupported action type"}
+	case segmentStart(conf, segment) >= segmentEnd(segment):
+		return SegmentPlan{Action: ActionIgnore, Reason: "shorter than the skip delay"}
+	default:
+		return SegmentPlan{Action: segment.ActionType}
+	}
+}
+
+func segmentStart(conf *config.Config, segment sponsorblock.Segment) float32 {
+	return segment.Segment[0] + float32(conf.SkipDelay.Seconds())
+}
+
+func segmentEnd(segment sponsorblock.Segment) float32 {
+	return segment.Segment[1] - 1
+}
+
+func segmentActive(conf *config.Config, segment sponsorblock.Segment, currentTime float32) bool {
+	return segmentStart(conf, segment) <= currentTime && currentTime < segmentEnd(segment)
+}
@@ -0,0 +1,64 @@
+package device
+
+import (
+	"testing"
+	"time"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"gabe565.com/castsponsorskip/internal/sponsorblock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanSegment(t *testing.T) {
+	tests := []struct {
+		name         string
+		skipSponsors bool
+		skipDelay    time.Duration
+		segment      sponsorblock.Segment
+		want         string
+	}{
+		{
+			"skip",
+			true,
+			0,
+			sponsorblock.Segment{Segment: [2]float32{10, 40}, ActionType: sponsorblock.ActionTypeSkip},
+			sponsorblock.ActionTypeSkip,
+		},
+		{
+			"mute",
+			true,
+			0,
+			sponsorblock.Segment{Segment: [2]float32{10, 40}, ActionType: sponsorblock.ActionTypeMute},
+			sponsorblock.ActionTypeMute,
+		},
+		{
+			"disabled",
+			false,
+			0,
+			sponsorblock.Segment{Segment: [2]float32{10, 40}, ActionType: sponsorblock.ActionTypeSkip},
+			ActionIgnore,
+		},
+		{
+			"full video label",
+			true,
+			0,
+			sponsorblock.Segment{Segment: [2]float32{0, 0}, ActionType: "full"},
+			ActionIgnore,
+		},
+		{
+			"shorter than delay",
+			true,
+			5 * time.Second,
+			sponsorblock.Segment{Segment: [2]float32{10, 15}, ActionType: sponsorblock.ActionTypeSkip},
+			ActionIgnore,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := config.New()
+			conf.SkipSponsors = tt.skipSponsors
+			conf.SkipDelay = tt.skipDelay
+			assert.Equal(t, tt.want, PlanSegment(conf, tt.segment).Action)
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package device
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"gabe565.com/castsponsorskip/internal/events"
+	"github.com/vishen/go-chromecast/cast"
+)
+
+const (
+	ConnectionConnecting   = "connecting"
+	ConnectionConnected    = "connected"
+	ConnectionReconnecting = "reconnecting"
+)
+
+type Status struct {
+	Name       string
+	Connection string
+	App        string
+	State      string
+	Title      string
+	Channel    string
+	VideoID    string
+
+	Position    time.Duration
+	Duration    time.Duration
+	NextSegment time.Duration
+	HasNext     bool
+	Muted       bool
+
+	UpdatedAt time.Time
+}
+
+func Statuses() []Status {
+	listenerMu.Lock()
+	statuses := make([]Status, 0, len(listeners))
+	for _, d := range listeners {
+		statuses = append(statuses, d.Status())
+	}
+	listenerMu.Unlock()
+
+	slices.SortFunc(statuses, func(a, b Status) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return statuses
+}
+
+func (d *Device) Status() Status {
+	d.statusMu.Lock()
+	defer d.statusMu.Unlock()
+	return d.status
+}
+
+func (d *Device) setConnection(connection string) {
+	d.statusMu.Lock()
+	defer d.statusMu.Unlock()
+	d.status.Connection = connection
+	d.status.UpdatedAt = time.Now()
+}
+
+// setAdMuted updates the muted status immediately, since skipping an ad can block the tick for a while.
+func (d *Device) setAdMuted(muted bool) {
+	d.adMuted = muted
+	d.statusMu.Lock()
+	defer d.statusMu.Unlock()
+	d.status.Muted = muted || d.mutedSegmentID != NoMutedSegment
+}
+
+func (d *Device) updateStatus(castApp *cast.Application, castMedia *cast.Media) {
+	status := Status{
+		Name:       d.entry.Name(),
+		Connection: ConnectionConnected,
+		VideoID:    d.meta.CurrVideoID,
+		Muted:      d.mutedSegmentID != NoMutedSegment || d.adMuted,
+		UpdatedAt:  time.Now(),
+	}
+
+	if castApp != nil {
+		status.App = castApp.DisplayName
+	}
+
+	if castMedia != nil {
+		status.State = castMedia.PlayerState
+		if castMedia.CustomData.PlayerState == StateAd {
+			status.State = "AD"
+		}
+		status.Title = castMedia.Media.Metadata.Title
+		status.Channel = castMedia.Media.Metadata.Artist
+		if status.Channel == "" {
+			status.Channel = castMedia.Media.Metadata.Subtitle
+		}
+		status.Position = secondsToDuration(castMedia.CurrentTime)
+		status.Duration = secondsToDuration(castMedia.Media.Duration)
+
+		for _, segment := range d.segments {
+			if segment.Segment[0] > castMedia.CurrentTime && PlanSegment(d.config, segment).Action != ActionIgnore {
+				if !status.HasNext || secondsToDuration(segment.Segment[0]) < status.NextSegment {
+					status.NextSegment = secondsToDuration(segment.Segment[0])
+					status.HasNext = true
+				}
+			}
+		}
+	}
+
+	d.statusMu.Lock()
+	defer d.statusMu.Unlock()
+	d.status = status
+	d.position = 0
+	if castMedia != nil {
+		d.position = castMedia.CurrentTime
+	}
+}
+
+func (d *Device) event(message string) {
+	events.Add(d.entry.Name(), message)
+}
+
+func secondsToDuration(seconds float32) time.Duration {
+	return time.Duration(seconds) * time.Second
+}
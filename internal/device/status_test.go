@@ -0,0 +1,36 @@
+package device
+
+import (
+	"testing"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vishen/go-chromecast/cast"
+	castdns "github.com/vishen/go-chromecast/dns"
+)
+
+func TestDevice_StatusMuted(t *testing.T) {
+	d := NewDevice(config.New(), Entry{CastEntry: castdns.CastEntry{UUID: "status", DeviceName: "Den TV"}})
+	require.NotNil(t, d)
+	t.Cleanup(func() { _ = d.Close() })
+
+	castApp := &cast.Application{DisplayName: "YouTube"}
+	castMedia := &cast.Media{PlayerState: StatePlaying}
+	castMedia.CustomData.PlayerState = StateAd
+
+	d.setAdMuted(true)
+	assert.True(t, d.Status().Muted, "ad mutes should be shown before the tick ends")
+	d.updateStatus(castApp, castMedia)
+	assert.True(t, d.Status().Muted)
+
+	d.setAdMuted(false)
+	assert.False(t, d.Status().Muted)
+
+	d.mutedSegmentID = 0
+	d.updateStatus(castApp, castMedia)
+	assert.True(t, d.Status().Muted)
+	d.setAdMuted(false)
+	assert.True(t, d.Status().Muted, "segment mutes should not be cleared by ads")
+	d.mutedSegmentID = NoMutedSegment
+}
@@ -0,0 +1,114 @@
+package device
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gabe565.com/castsponsorskip/internal/sponsorblock"
+)
+
+var (
+	ErrNoVideo        = errors.New("no YouTube video is playing")
+	ErrNoSegmentStart = errors.New("segment start was not marked")
+	ErrVideoChanged   = errors.New("video changed since the segment start was marked")
+)
+
+type segmentMark struct {
+	videoID string
+	start   float32
+}
+
+// Find returns a connected device by UUID or friendly name.
+func Find(nameOrUUID string) (*Device, error) {
+	listenerMu.Lock()
+	defer listenerMu.Unlock()
+
+	if d, ok := listeners[nameOrUUID]; ok {
+		return d, nil
+	}
+	for _, d := range listeners {
+		if strings.EqualFold(d.entry.Name(), nameOrUUID) {
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrDeviceNotFound, nameOrUUID)
+}
+
+// currentTime returns the current video and playback position in seconds.
+// The position of a playing video is extrapolated from the last status update.
+// statusMu must be held.
+func (d *Device) currentTime() (string, float32, error) {
+	if d.status.App != "YouTube" || d.status.VideoID == "" {
+		return "", 0, ErrNoVideo
+	}
+
+	position := d.position
+	if d.status.State == StatePlaying {
+		position += float32(time.Since(d.status.UpdatedAt).Seconds())
+	}
+	return d.status.VideoID, position, nil
+}
+
+// MarkSegmentStart marks the start of a new segment at the current playback position.
+func (d *Device) MarkSegmentStart() (string, float32, error) {
+	d.statusMu.Lock()
+	defer d.statusMu.Unlock()
+
+	videoID, start, err := d.currentTime()
+	if err != nil {
+		return "", 0, err
+	}
+	d.mark = &segmentMark{videoID: videoID, start: start}
+
+	d.logger.Info("Marked segment start.", "video_id", videoID, "at", secondsToDuration(start))
+	d.event("Marked segment start at " + secondsToDuration(start).String())
+	return videoID, start, nil
+}
+
+// SubmitSegment submits a segment from the marked start to the current playback position,
+// then asks the tick loop to refresh the segments of the current video.
+func (d *Device) SubmitSegment(ctx context.Context, category, actionType string) (sponsorblock.Segment, error) {
+	d.statusMu.Lock()
+	videoID, end, err := d.currentTime()
+	mark := d.mark
+	duration := d.status.Duration
+	d.statusMu.Unlock()
+
+	switch {
+	case err != nil:
+		return sponsorblock.Segment{}, err
+	case mark == nil:
+		return sponsorblock.Segment{}, ErrNoSegmentStart
+	case mark.videoID != videoID:
+		return sponsorblock.Segment{}, ErrVideoChanged
+	}
+
+	segment, err := sponsorblock.SubmitSegment(ctx, d.config, sponsorblock.Submission{
+		VideoID:       videoID,
+		Start:         mark.start,
+		End:           end,
+		Category:      category,
+		ActionType:    actionType,
+		VideoDuration: float32(duration.Seconds()),
+	})
+	if err != nil {
+		d.logger.Error("Failed to submit segment.", "video_id", videoID, "error", err.Error())
+		return sponsorblock.Segment{}, err
+	}
+
+	d.statusMu.Lock()
+	if d.mark == mark {
+		d.mark = nil
+	}
+	d.statusMu.Unlock()
+
+	from, to := secondsToDuration(segment.Segment[0]), secondsToDuration(segment.Segment[1])
+	d.logger.Info("Submitted segment.", "category", segment.Category, "from", from, "to", to, "uuid", segment.UUID)
+	d.event("Submitted " + segment.Category + " from " + from.String() + " to " + to.String())
+
+	d.requestRefresh()
+	return segment, nil
+}
@@ -0,0 +1,116 @@
+package device
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"gabe565.com/castsponsorskip/internal/sponsorblock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vishen/go-chromecast/cast"
+	castdns "github.com/vishen/go-chromecast/dns"
+)
+
+func TestDevice_SubmitSegment(t *testing.T) {
+	const videoID = "dQw4w9WgXcQ"
+	var submitted []sponsorblock.Segment
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			var req struct {
+				VideoID  string                 `json:"videoID"`
+				Segments []sponsorblock.Segment `json:"segments"`
+			}
+			if !assert.NoError(t, json.NewDecoder(r.Body).Decode(&req)) {
+				return
+			}
+			assert.Equal(t, videoID, req.VideoID)
+			segment := req.Segments[0]
+			segment.UUID = "new-uuid"
+			submitted = append(submitted, segment)
+			_ = json.NewEncoder(w).Encode([]sponsorblock.Segment{segment})
+			return
+		}
+		if len(submitted) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode([]sponsorblock.Video{{VideoID: videoID, Segments: submitted}})
+	}))
+	t.Cleanup(server.Close)
+
+	conf := config.New()
+	conf.SponsorBlockUserID = "0123456789abcdef0123456789abcdef"
+	var err error
+	conf.SponsorBlockServer, err = url.Parse(server.URL)
+	require.NoError(t, err)
+
+	d := NewDevice(conf, Entry{CastEntry: castdns.CastEntry{UUID: "submit", DeviceName: "Living Room TV"}})
+	require.NotNil(t, d)
+	t.Cleanup(func() { _ = d.Close() })
+
+	found, err := Find("living room tv")
+	require.NoError(t, err)
+	assert.Same(t, d, found)
+	_, err = Find("missing")
+	require.ErrorIs(t, err, ErrDeviceNotFound)
+
+	castApp := &cast.Application{DisplayName: "YouTube"}
+	castMedia := &cast.Media{PlayerState: "PAUSED", Media: cast.MediaItem{Duration: 213}}
+	seek := func(position float32) {
+		castMedia.CurrentTime = position
+		d.updateStatus(castApp, castMedia)
+	}
+
+	seek(10)
+	_, _, err = d.MarkSegmentStart()
+	require.ErrorIs(t, err, ErrNoVideo)
+
+	d.meta.CurrVideoID = videoID
+	seek(10)
+	_, err = d.SubmitSegment(t.Context(), "sponsor", "")
+	require.ErrorIs(t, err, ErrNoSegmentStart)
+
+	seek(10.5)
+	id, start, err := d.MarkSegmentStart()
+	require.NoError(t, err)
+	assert.Equal(t, videoID, id)
+	assert.InDelta(t, 10.5, start, 0.001)
+
+	seek(42)
+	segment, err := d.SubmitSegment(t.Context(), "sponsor", sponsorblock.ActionTypeSkip)
+	require.NoError(t, err)
+	assert.Equal(t, "new-uuid", segment.UUID)
+	assert.Equal(t, [2]float32{10.5, 42}, segment.Segment)
+	assert.InDelta(t, 213, segment.VideoDuration, 0.001)
+
+	// The tick loop is asked to refresh segments after submitting
+	select {
+	case <-d.refresh:
+	default:
+		t.Fatal("refresh was not requested")
+	}
+	d.startRefresh()
+	select {
+	case r := <-d.refreshed:
+		d.applyRefresh(r)
+	case <-time.After(5 * time.Second):
+		t.Fatal("segments were not refreshed")
+	}
+	require.Len(t, d.segments, 1)
+	assert.Equal(t, "new-uuid", d.segments[0].UUID)
+
+	_, err = d.SubmitSegment(t.Context(), "sponsor", sponsorblock.ActionTypeSkip)
+	require.ErrorIs(t, err, ErrNoSegmentStart)
+
+	_, _, err = d.MarkSegmentStart()
+	require.NoError(t, err)
+	d.meta.CurrVideoID = "other"
+	seek(50)
+	_, err = d.SubmitSegment(t.Context(), "sponsor", sponsorblock.ActionTypeSkip)
+	require.ErrorIs(t, err, ErrVideoChanged)
+}
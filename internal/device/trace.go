@@ -0,0 +1,89 @@
+package device
+
+import (
+	"context"
+
+	"gabe565.com/castsponsorskip/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func (d *Device) traceAttrs() []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.String("device.name", d.entry.Name()),
+		attribute.String("device.uuid", d.entry.UUID),
+		attribute.String("device.model", d.entry.Device),
+		attribute.String("device.address", d.entry.GetAddr()),
+		attribute.Bool("device.group", d.entry.IsGroup()),
+	}
+	if d.entry.Interface != nil {
+		attrs = append(attrs, attribute.String("device.interface", d.entry.Interface.Name))
+	}
+	return attrs
+}
+
+// traceContext returns the context of the current video session, or of the device connection if no video is playing.
+func (d *Device) traceContext() context.Context {
+	d.traceMu.Lock()
+	defer d.traceMu.Unlock()
+	switch {
+	case d.videoCtx != nil:
+		return d.videoCtx
+	case d.connCtx != nil:
+		return d.connCtx
+	case d.ctx != nil:
+		return d.ctx
+	default:
+		return context.Background()
+	}
+}
+
+func (d *Device) startVideoSession(videoID string) context.Context {
+	d.endVideoSession()
+
+	ctx, span := tracing.Start(d.traceContext(), "video.session",
+		trace.WithAttributes(attribute.String("video_id", videoID)),
+	)
+
+	d.traceMu.Lock()
+	defer d.traceMu.Unlock()
+	d.videoCtx, d.videoSpan = ctx, span
+	return ctx
+}
+
+func (d *Device) endVideoSession() {
+	d.traceMu.Lock()
+	defer d.traceMu.Unlock()
+	if d.videoSpan != nil {
+		d.videoSpan.End()
+	}
+	d.videoCtx, d.videoSpan = nil, nil
+}
+
+// command runs a device command in a child span of the current video session.
+func (d *Device) command(name string, fn func() error, attrs ...attribute.KeyValue) error {
+	_, span := tracing.Start(d.traceContext(), name, trace.WithAttributes(attrs...))
+	err := fn()
+	tracing.End(span, err)
+	return err
+}
+
+// setMuted mutes or unmutes the device and records it in the mute state.
+// A mute is recorded before it is sent, so the device is still unmuted if the process stops right after muting it.
+// An unmute is only recorded once it succeeds.
+func (d *Device) setMuted(muted bool, attrs ...attribute.KeyValue) error {
+	var recorded bool
+	if muted {
+		recorded = d.saveMuted(true)
+	}
+	err := d.command("device.mute", func() error {
+		return d.app.SetMuted(muted)
+	}, append(attrs, attribute.Bool("muted", muted))...)
+	switch {
+	case err != nil && recorded:
+		d.saveMuted(false)
+	case err == nil && !muted:
+		d.saveMuted(false)
+	}
+	return err
+}
@@ -1,9 +1,12 @@
 package device
 
+import "time"
+
 type VideoMeta struct {
-	CurrVideoID string
-	CurrArtist  string
-	CurrTitle   string
+	CurrVideoID  string
+	CurrArtist   string
+	CurrTitle    string
+	CurrDuration time.Duration
 
 	PrevVideoID string
 	PrevArtist  string
@@ -14,6 +17,7 @@ func (v *VideoMeta) Clear() {
 	v.CurrVideoID = ""
 	v.CurrArtist = ""
 	v.CurrTitle = ""
+	v.CurrDuration = 0
 	v.PrevVideoID = ""
 	v.PrevArtist = ""
 	v.PrevTitle = ""
@@ -10,14 +10,17 @@ import (
 	"time"
 
 	"gabe565.com/castsponsorskip/internal/config"
+	"gabe565.com/castsponsorskip/internal/history"
 	"gabe565.com/castsponsorskip/internal/sponsorblock"
+	"gabe565.com/castsponsorskip/internal/tracing"
 	"gabe565.com/castsponsorskip/internal/util"
 	"gabe565.com/castsponsorskip/internal/youtube"
 	"github.com/buger/jsonparser"
 	"github.com/vishen/go-chromecast/application"
 	"github.com/vishen/go-chromecast/cast"
 	api "github.com/vishen/go-chromecast/cast/proto"
-	castdns "github.com/vishen/go-chromecast/dns"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -32,20 +35,34 @@ const (
 
 //nolint:gochecknoglobals
 var (
-	listeners  = make(map[string]struct{})
+	listeners  = make(map[string]*Device)
 	listenerMu sync.Mutex
 )
 
+// castApplication is the part of application.Application used by a device, so tests can replace it.
+type castApplication interface {
+	AddMessageFunc(f application.CastMessageFunc)
+	Start(addr string, port int) error
+	Update() error
+	Close(stopMedia bool) error
+	Status() (*cast.Application, *cast.Media, *cast.Volume)
+	Skipad() error
+	SeekToTime(value float32) error
+	SetMuted(value bool) error
+}
+
 type Device struct {
 	config *config.Config
 	ctx    context.Context
 	cancel context.CancelFunc
 
 	mu     sync.Mutex
-	entry  castdns.CastEntry
+	entry  Entry
 	opts   []application.ApplicationOption
-	app    *application.Application
+	app    castApplication
 	logger *slog.Logger
+	// castLogger logs cast protocol traffic
+	castLogger *slog.Logger
 
 	tickInterval time.Duration
 	ticker       *time.Ticker
@@ -56,44 +73,98 @@ type Device struct {
 	prevSegmentIdx    int
 	prevSegmentIgnore time.Time
 	mutedSegmentID    int
+	// inAd is true while an ad is playing, so each ad is only announced once
+	inAd bool
+	// adMuted is true while the device is muted for an ad
+	adMuted bool
+	// ignoringSegment is true while playback is in the previously skipped segment
+	ignoringSegment bool
+	// seekBacks counts how often playback returned to each skipped segment of the current video
+	seekBacks map[string]int
+
+	// refresh asks the tick loop to query the segments of the current video again
+	refresh   chan struct{}
+	refreshed chan refreshedSegments
+
+	reporter *sponsorblock.Reporter
+	history  *history.Recorder
+	// reported holds the segments of the current video that were reported as skipped
+	reported map[string]struct{}
+
+	sessionID string
+	group     *Device
+
+	statusMu sync.Mutex
+	status   Status
+	// position is the unrounded playback position of the last status update
+	position float32
+	mark     *segmentMark
+
+	traceMu   sync.Mutex
+	connCtx   context.Context //nolint:containedctx
+	videoCtx  context.Context //nolint:containedctx
+	videoSpan trace.Span
 }
 
-func NewDevice(config *config.Config, entry castdns.CastEntry, opts ...Option) *Device {
-	if entry.Device == "Google Cast Group" {
-		return nil
-	} else if entry.Device == "" && entry.DeviceName == "" && entry.UUID == "" {
+func NewDevice(conf *config.Config, entry Entry, opts ...Option) *Device {
+	if entry.Device == "" && entry.DeviceName == "" && entry.UUID == "" {
 		return nil
 	}
 
-	var logger *slog.Logger
-	if entry.DeviceName != "" {
-		logger = slog.With("device", entry.DeviceName)
-	} else {
-		logger = slog.With("device", entry.Device)
+	logger := slog.With(
+		"device", entry.Name(),
+		config.LogKeyDeviceUUID, entry.UUID,
+		config.LogKeySubsystem, config.SubsystemTick,
+	)
+	if entry.Interface != nil {
+		logger = logger.With("interface", entry.Interface.Name)
 	}
 
-	if hasVideoOut, err := HasVideoOut(entry); err == nil && !hasVideoOut {
-		logger.Debug("Ignoring device.", "reason", "Does not support video")
-		return nil
+	if entry.IsGroup() {
+		logger = logger.With("group", true)
 	}
 
-	listenerMu.Lock()
-	if _, ok := listeners[entry.UUID]; ok {
-		listenerMu.Unlock()
-		logger.Debug("Ignoring device.", "reason", "Already connected")
-		return nil
+	if hasVideoOut, err := HasVideoOut(entry.CastEntry); err == nil && !hasVideoOut {
+		switch {
+		case entry.IsGroup() && groupHasVideoOut(entry):
+			// Groups do not advertise video support, so it is checked on the group's leader
+		case conf.AudioDevices:
+			conf = conf.ForAudioDevice()
+		default:
+			logger.Debug("Ignoring device.", "reason", "Does not support video")
+			return nil
+		}
 	}
-	listeners[entry.UUID] = struct{}{}
-	listenerMu.Unlock()
 
 	device := &Device{
-		config:         config,
+		config:         conf,
 		entry:          entry,
 		logger:         logger,
+		castLogger:     logger.With(config.LogKeySubsystem, config.SubsystemCast),
 		mutedSegmentID: NoMutedSegment,
 		prevSegmentIdx: NoSkippedSegment,
+		refresh:        make(chan struct{}, 1),
+		refreshed:      make(chan refreshedSegments, 1),
+		status: Status{
+			Name:       entry.Name(),
+			Connection: ConnectionConnecting,
+			UpdatedAt:  time.Now(),
+		},
 	}
 
+	listenerMu.Lock()
+	if _, ok := listeners[entry.UUID]; ok {
+		// Rediscovered devices may have a new address
+		rememberEntry(entry)
+		listenerMu.Unlock()
+		logger.Debug("Ignoring device.", "reason", "Already connected")
+		return nil
+	}
+	listeners[entry.UUID] = device
+	// Ignored devices are not remembered, so a group led by an ignored speaker is not watched as a video group
+	rememberEntry(entry)
+	listenerMu.Unlock()
+
 	for _, opt := range opts {
 		opt(device)
 	}
@@ -105,10 +176,12 @@ func (d *Device) Close() error {
 	defer func() {
 		listenerMu.Lock()
 		delete(listeners, d.entry.UUID)
+		forgetEntry(d.entry.UUID)
 		listenerMu.Unlock()
 	}()
 
 	d.unmuteSegment()
+	d.releaseGroupSession()
 	if d.cancel != nil {
 		d.cancel()
 	}
@@ -133,7 +206,7 @@ func (d *Device) closeApp() error {
 	return nil
 }
 
-func (d *Device) BeginTick(opts ...application.ApplicationOption) error {
+func (d *Device) BeginTick(opts ...application.ApplicationOption) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			d.logger.Error("Recovered from panic.", "error", r)
@@ -141,7 +214,20 @@ func (d *Device) BeginTick(opts ...application.ApplicationOption) error {
 		}
 	}()
 
-	if err := d.connect(opts...); err != nil {
+	ctx, span := tracing.Start(d.ctx, "device.connection", trace.WithAttributes(d.traceAttrs()...))
+	d.traceMu.Lock()
+	d.connCtx = ctx
+	d.traceMu.Unlock()
+	defer func() {
+		d.endVideoSession()
+		spanErr := err
+		if errors.Is(spanErr, context.Canceled) {
+			spanErr = nil
+		}
+		tracing.End(span, spanErr)
+	}()
+
+	if err := d.connect(ctx, opts...); err != nil {
 		d.logger.Error("Failed to connect to device.", "error", err.Error())
 		return err
 	}
@@ -160,6 +246,10 @@ func (d *Device) BeginTick(opts ...application.ApplicationOption) error {
 				d.logger.Error("Lost connection to device.", "error", err.Error())
 				return err
 			}
+		case <-d.refresh:
+			d.startRefresh()
+		case r := <-d.refreshed:
+			d.applyRefresh(r)
 		}
 	}
 }
@@ -170,8 +260,18 @@ func (d *Device) tick() error {
 	}
 
 	castApp, castMedia, castVol := d.app.Status()
+	defer d.updateStatus(castApp, castMedia)
 
 	if castApp == nil || castApp.DisplayName != "YouTube" || castMedia == nil {
+		d.inAd = false
+		d.releaseGroupSession()
+		d.changeTickInterval(d.config.PausedInterval)
+		return nil
+	}
+
+	if d.entry.IsGroup() {
+		d.claimGroupSession(castApp.SessionId)
+	} else if d.deferToGroup(castApp.SessionId) {
 		d.changeTickInterval(d.config.PausedInterval)
 		return nil
 	}
@@ -185,12 +285,14 @@ func (d *Device) tick() error {
 	case StateAd:
 		d.muteAd(castVol)
 	default:
+		d.inAd = false
 		if castMedia.Media.Metadata.Artist != "" {
 			d.meta.CurrArtist = castMedia.Media.Metadata.Artist
 		} else {
 			d.meta.CurrArtist = castMedia.Media.Metadata.Subtitle
 		}
 		d.meta.CurrTitle = castMedia.Media.Metadata.Title
+		d.meta.CurrDuration = secondsToDuration(castMedia.Media.Duration)
 
 		switch {
 		case !d.config.SkipSponsors:
@@ -209,21 +311,20 @@ func (d *Device) tick() error {
 		if d.meta.CurrVideoID != d.meta.PrevVideoID {
 			d.segments = nil
 			d.prevSegmentIdx = NoSkippedSegment
+			d.seekBacks = nil
+			d.reported = nil
+			d.endVideoSession()
 			if d.meta.CurrVideoID != "" {
 				d.logger.Info("Detected video stream.", "video_id", d.meta.CurrVideoID)
+				d.event("Detected video " + d.meta.CurrVideoID)
 				d.meta.PrevVideoID = d.meta.CurrVideoID
-				go d.querySegments()
+				go d.querySegments(d.startVideoSession(d.meta.CurrVideoID))
 			}
 			d.unmuteSegment()
 			break
 		}
 
-		for i, segment := range d.segments {
-			if (segment.Segment[0]+float32(d.config.SkipDelay.Seconds())) <= castMedia.CurrentTime &&
-				castMedia.CurrentTime < segment.Segment[1]-1 {
-				d.handleSegment(castMedia, castVol, segment, i)
-			}
-		}
+		d.handleSegments(castMedia, castVol)
 
 		if d.mutedSegmentID != NoMutedSegment {
 			segment := d.segments[d.mutedSegmentID]
@@ -231,6 +332,7 @@ func (d *Device) tick() error {
 				from := time.Duration(castMedia.CurrentTime) * time.Second
 				to := time.Duration(segment.Segment[1]) * time.Second
 				d.logger.Info("Unmute segment.", "category", segment.Category, "from", from, "to", to)
+				d.event("Unmuted " + segment.Category + " at " + from.String())
 				d.unmuteSegment()
 			}
 		}
@@ -244,7 +346,12 @@ func (d *Device) tick() error {
 	return nil
 }
 
-func (d *Device) connect(opts ...application.ApplicationOption) error {
+func (d *Device) connect(ctx context.Context, opts ...application.ApplicationOption) (err error) {
+	_, span := tracing.Start(ctx, "device.connect")
+	defer func() {
+		tracing.End(span, err)
+	}()
+
 	logLevel := slog.LevelInfo
 	if d.app != nil {
 		logLevel = slog.LevelDebug
@@ -255,20 +362,25 @@ func (d *Device) connect(opts ...application.ApplicationOption) error {
 		opts,
 		application.WithSkipadSleep(d.config.PlayingInterval),
 		application.WithSkipadRetries(int(time.Minute/d.config.PlayingInterval)),
-		application.WithIface(d.config.NetworkInterface),
+		application.WithIface(d.entry.Interface),
 	)
 	d.app = application.NewApplication(opts...)
 	d.app.AddMessageFunc(d.onMessage)
 
 	if err := util.Retry(d.ctx, 6, 500*time.Millisecond, func(try uint) error {
 		if err := d.app.Start(d.entry.GetAddr(), d.entry.GetPort()); err != nil {
-			d.logger.Debug("Failed to connect to device. Retrying...", "try", try, "error", err.Error())
+			d.castLogger.Debug("Failed to connect to device. Retrying...", "try", try, "error", err.Error())
+			span.AddEvent("retry", trace.WithAttributes(
+				attribute.Int("try", int(try)),
+				attribute.String("error", err.Error()),
+			))
 
-			newEntry, subErr := DiscoverCastDNSEntryByUUID(d.ctx, d.config, d.entry.UUID)
+			newEntry, subErr := DiscoverCastDNSEntryByUUID(d.ctx, d.entry.Interface, d.entry.UUID)
 			if subErr != nil {
 				return subErr
 			}
-			d.entry = newEntry
+			d.entry.CastEntry = newEntry
+			rememberEntry(d.entry)
 
 			return err
 		}
@@ -276,9 +388,11 @@ func (d *Device) connect(opts ...application.ApplicationOption) error {
 	}); err != nil {
 		return err
 	}
+	d.setConnection(ConnectionConnected)
 	if d.ctx.Err() == nil {
-		d.logger.Log(d.ctx, logLevel, "Connected to cast device.")
+		d.castLogger.Log(d.ctx, logLevel, "Connected to cast device.")
 	}
+	d.restoreMute()
 
 	return nil
 }
@@ -286,6 +400,7 @@ func (d *Device) connect(opts ...application.ApplicationOption) error {
 func (d *Device) onMessage(msg *api.CastMessage) {
 	payload := []byte(msg.GetPayloadUtf8())
 	msgType, _ := jsonparser.GetString(payload, "type")
+	d.castLogger.Debug("Received message.", "type", msgType)
 	switch msgType {
 	case "RECEIVER_STATUS":
 		appID, _ := jsonparser.GetString(payload, "status", "applications", "[0]", "displayName")
@@ -315,22 +430,30 @@ func (d *Device) onMessage(msg *api.CastMessage) {
 		d.segments = nil
 		d.prevSegmentIdx = NoSkippedSegment
 		d.meta.Clear()
+		d.endVideoSession()
 	}
 }
 
 func (d *Device) update() error {
-	d.logger.Debug("Requesting update.")
+	d.castLogger.Debug("Requesting update.")
 
 	err := d.app.Update()
 	if err != nil {
-		d.logger.Debug("Failed to update device. Reconnecting...", "error", err.Error())
-
-		if subErr := d.connect(d.opts...); subErr != nil {
-			d.logger.Debug("Failed to reconnect.", "error", subErr.Error())
+		d.castLogger.Debug("Failed to update device. Reconnecting...", "error", err.Error())
+		d.setConnection(ConnectionReconnecting)
+
+		ctx, span := tracing.Start(d.traceContext(), "device.reconnect",
+			trace.WithAttributes(attribute.String("cause", err.Error())),
+		)
+		if subErr := d.connect(ctx, d.opts...); subErr != nil {
+			d.castLogger.Debug("Failed to reconnect.", "error", subErr.Error())
+			tracing.End(span, subErr)
 			return err
 		}
 
-		if subErr := d.app.Update(); subErr == nil {
+		subErr := d.app.Update()
+		tracing.End(span, subErr)
+		if subErr == nil {
 			return nil
 		}
 	}
@@ -349,15 +472,20 @@ func (d *Device) queryVideoID() {
 	d.segments = nil
 	d.prevSegmentIdx = NoSkippedSegment
 
-	if d.config.YouTubeAPIKey == "" {
-		d.logger.Error("Video ID not set. Please configure a YouTube API key.")
+	if !youtube.Enabled() {
+		d.logger.Error("Video ID not set. Please configure a YouTube API key or another video ID backend.")
 	} else {
-		d.logger.Info("Video ID not set. Searching YouTube for video ID...")
+		d.logger.Info("Video ID not set. Searching for video ID...")
+		ctx := d.traceContext()
 		go func() {
-			err := util.Retry(d.ctx, 3, time.Second, func(_ uint) error {
-				contentID, err := youtube.QueryVideoID(d.ctx, d.meta.CurrArtist, d.meta.CurrTitle)
+			err := util.Retry(ctx, 3, time.Second, func(_ uint) error {
+				contentID, err := youtube.FindVideoID(ctx, youtube.Query{
+					Artist:   d.meta.CurrArtist,
+					Title:    d.meta.CurrTitle,
+					Duration: d.meta.CurrDuration,
+				})
 				if err != nil {
-					d.logger.Error("YouTube search failed.", "error", err.Error())
+					d.logger.Error("Video ID search failed.", "error", err.Error())
 					return err
 				}
 
@@ -365,9 +493,9 @@ func (d *Device) queryVideoID() {
 				return nil
 			})
 			if err == nil {
-				d.logger.Debug("YouTube search returned video ID.", "video_id", d.meta.CurrVideoID)
+				d.logger.Debug("Video ID search returned video ID.", "video_id", d.meta.CurrVideoID)
 			} else {
-				d.logger.Debug("Halting YouTube search retries.")
+				d.logger.Debug("Halting video ID search retries.")
 			}
 		}()
 	}
@@ -377,8 +505,9 @@ func (d *Device) muteAd(castVol *cast.Volume) {
 	var shouldUnmute bool
 	if d.config.MuteAds && !castVol.Muted {
 		d.logger.Info("Detected ad. Muting and attempting to skip...")
-		if err := d.app.SetMuted(true); err == nil {
+		if err := d.setMuted(true, attribute.String("reason", "ad")); err == nil {
 			shouldUnmute = true
+			d.setAdMuted(true)
 		} else {
 			d.logger.Warn("Failed to mute ad.", "error", err.Error())
 		}
@@ -386,19 +515,41 @@ func (d *Device) muteAd(castVol *cast.Volume) {
 		d.logger.Info("Detected ad. Attempting to skip...")
 	}
 
+	if !d.inAd {
+		d.inAd = true
+		d.event("Detected ad")
+	}
 	if err := d.app.Skipad(); err == nil {
 		d.logger.Info("Skipped ad.")
+		d.event("Skipped ad")
 	} else if !errors.Is(err, application.ErrNoMediaSkipad) {
 		d.logger.Warn("Failed to skip ad.", "error", err.Error())
 	}
 
 	if shouldUnmute {
-		if err := d.app.SetMuted(false); err != nil {
+		if err := d.setMuted(false, attribute.String("reason", "ad")); err == nil {
+			d.setAdMuted(false)
+		} else {
 			d.logger.Warn("Failed to unmute ad.", "error", err.Error())
 		}
 	}
 }
 
+func (d *Device) handleSegments(castMedia *cast.Media, castVol *cast.Volume) {
+	wasIgnoring := d.ignoringSegment
+	d.ignoringSegment = false
+
+	for i, segment := range d.segments {
+		if segmentActive(d.config, segment, castMedia.CurrentTime) {
+			d.handleSegment(castMedia, castVol, segment, i)
+		}
+	}
+
+	if d.ignoringSegment && !wasIgnoring {
+		d.seekedBack(d.segments[d.prevSegmentIdx])
+	}
+}
+
 func (d *Device) handleSegment(castMedia *cast.Media, castVol *cast.Volume, segment sponsorblock.Segment, i int) {
 	from := time.Duration(castMedia.CurrentTime) * time.Second
 	to := time.Duration(segment.Segment[1]) * time.Second
@@ -414,23 +565,34 @@ func (d *Device) handleSegment(castMedia *cast.Media, castVol *cast.Volume, segm
 					"until", d.prevSegmentIgnore.Truncate(time.Second).String(),
 				)
 				d.prevSegmentIgnore = now.Add(d.config.IgnoreSegmentDuration)
+				d.ignoringSegment = true
 				return
 			}
 		}
 
 		d.logger.Info("Skipping to timestamp.", "category", segment.Category, "from", from, "to", to)
+		d.event("Skipped " + segment.Category + " from " + from.String() + " to " + to.String())
 		// Cast API seems to ignore decimals, so add 100ms to seek time in case sponsorship ends at 0.9 seconds.
-		if err := d.app.SeekToTime(segment.Segment[1] + 0.1); err != nil {
+		seek := func() error { return d.app.SeekToTime(segment.Segment[1] + 0.1) }
+		if err := d.command("device.seek", seek,
+			attribute.String("category", segment.Category),
+			attribute.Float64("from", float64(castMedia.CurrentTime)),
+			attribute.Float64("to", float64(segment.Segment[1])),
+		); err != nil {
 			d.logger.Warn("Failed to seek to timestamp.", "to", segment.Segment[1], "error", err.Error())
 		}
 		castMedia.CurrentTime = segment.Segment[1]
 		d.prevSegmentIdx = i
 		d.prevSegmentIgnore = time.Now().Add(d.config.IgnoreSegmentDuration)
+		d.recordHistory(segment)
+		d.reportSkip(segment)
 	case sponsorblock.ActionTypeMute:
 		if !castVol.Muted || i != d.mutedSegmentID {
 			d.logger.Info("Mute segment.", "category", segment.Category, "from", from, "to", to)
-			if err := d.app.SetMuted(true); err == nil {
+			if err := d.setMuted(true, attribute.String("category", segment.Category)); err == nil {
+				d.event("Muted " + segment.Category + " from " + from.String() + " to " + to.String())
 				d.mutedSegmentID = i
+				d.recordHistory(segment)
 			} else {
 				d.logger.Warn("Failed to mute "+segment.Category+".", "error", err.Error())
 			}
@@ -438,9 +600,24 @@ func (d *Device) handleSegment(castMedia *cast.Media, castVol *cast.Volume, segm
 	}
 }
 
+// reportSkip queues a skip report once per segment and video session.
+func (d *Device) reportSkip(segment sponsorblock.Segment) {
+	if d.reporter == nil || segment.UUID == "" {
+		return
+	}
+	if _, ok := d.reported[segment.UUID]; ok {
+		return
+	}
+	if d.reported == nil {
+		d.reported = make(map[string]struct{})
+	}
+	d.reported[segment.UUID] = struct{}{}
+	d.reporter.Report(segment.UUID)
+}
+
 func (d *Device) unmuteSegment() {
 	if d.mutedSegmentID != NoMutedSegment {
-		if err := d.app.SetMuted(false); err == nil {
+		if err := d.setMuted(false); err == nil {
 			d.mutedSegmentID = NoMutedSegment
 		} else {
 			d.logger.Warn("Failed to unmute after video change.", "error", err.Error())
@@ -448,14 +625,14 @@ func (d *Device) unmuteSegment() {
 	}
 }
 
-func (d *Device) querySegments() {
+func (d *Device) querySegments(ctx context.Context) {
 	if d.meta.CurrVideoID == "" {
 		return
 	}
 
-	if err := util.Retry(d.ctx, 10, 500*time.Millisecond, func(_ uint) error {
+	if err := util.Retry(ctx, 10, 500*time.Millisecond, func(_ uint) error {
 		var err error
-		d.segments, err = sponsorblock.QuerySegments(d.ctx, d.config, d.meta.CurrVideoID)
+		d.segments, err = sponsorblock.QuerySegments(ctx, d.config, d.meta.CurrVideoID)
 		return err
 	}); err == nil {
 		if len(d.segments) == 0 {
@@ -0,0 +1,141 @@
+package device
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"gabe565.com/castsponsorskip/internal/sponsorblock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	castdns "github.com/vishen/go-chromecast/dns"
+)
+
+func TestNewDevice_AudioOnly(t *testing.T) {
+	speaker := Entry{CastEntry: castdns.CastEntry{
+		UUID:       "speaker",
+		DeviceName: "Kitchen speaker",
+		Device:     "Google Nest Mini",
+		InfoFields: map[string]string{"ca": "199172"},
+	}}
+	tv := Entry{CastEntry: castdns.CastEntry{
+		UUID:       "tv",
+		DeviceName: "Living Room TV",
+		Device:     "Chromecast",
+		InfoFields: map[string]string{"ca": "201221"},
+		AddrV4:     net.IPv4(192, 168, 1, 10),
+		Port:       8009,
+	}}
+	speakerGroup := Entry{CastEntry: castdns.CastEntry{
+		UUID:       "speaker-group",
+		DeviceName: "Downstairs speakers",
+		Device:     CastGroup,
+		InfoFields: map[string]string{"ca": "199172"},
+		AddrV4:     net.IPv4(192, 168, 1, 11),
+		Port:       32187,
+	}}
+	tvGroup := Entry{CastEntry: castdns.CastEntry{
+		UUID:       "tv-group",
+		DeviceName: "Downstairs",
+		Device:     CastGroup,
+		InfoFields: map[string]string{"ca": "199172"},
+		AddrV4:     net.IPv4(192, 168, 1, 10),
+		Port:       32187,
+	}}
+	t.Cleanup(func() {
+		knownEntries = make(map[string]Entry)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		conf := config.New()
+		assert.Nil(t, NewDevice(conf, speaker))
+		assert.Nil(t, NewDevice(conf, speakerGroup))
+		assert.Nil(t, NewDevice(conf, tvGroup), "groups are ignored until their leader is known")
+
+		d := NewDevice(conf, tv)
+		require.NotNil(t, d)
+		t.Cleanup(func() { _ = d.Close() })
+		assert.Equal(t, conf.Categories, d.config.Categories)
+
+		g := NewDevice(conf, tvGroup)
+		require.NotNil(t, g)
+		t.Cleanup(func() { _ = g.Close() })
+		assert.Equal(t, conf.Categories, g.config.Categories)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		conf := config.New()
+		conf.AudioDevices = true
+
+		d := NewDevice(conf, speaker)
+		require.NotNil(t, d)
+		t.Cleanup(func() { _ = d.Close() })
+		assert.Equal(t, conf.AudioCategories, d.config.Categories)
+		assert.Equal(t, []string{"sponsor"}, conf.Categories)
+
+		g := NewDevice(conf, speakerGroup)
+		require.NotNil(t, g)
+		t.Cleanup(func() { _ = g.Close() })
+		assert.Equal(t, conf.AudioCategories, g.config.Categories)
+	})
+}
+
+func TestDevice_reportSkip(t *testing.T) {
+	var mu sync.Mutex
+	var reported []string
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		reported = append(reported, r.URL.Query().Get("UUID"))
+	}))
+	t.Cleanup(server.Close)
+	getReported := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(reported)
+	}
+
+	conf := config.New()
+	var err error
+	conf.SponsorBlockServer, err = url.Parse(server.URL)
+	require.NoError(t, err)
+
+	reporter := sponsorblock.NewReporter(conf, 10*time.Millisecond)
+	ctx, cancel := context.WithCancel(t.Context())
+	go reporter.Run(ctx)
+	t.Cleanup(func() {
+		cancel()
+		reporter.Wait()
+	})
+
+	d := NewDevice(conf, Entry{CastEntry: castdns.CastEntry{UUID: "report", DeviceName: "Office TV"}}, WithReporter(reporter))
+	require.NotNil(t, d)
+	t.Cleanup(func() { _ = d.Close() })
+
+	d.reportSkip(sponsorblock.Segment{UUID: "a"})
+	d.reportSkip(sponsorblock.Segment{UUID: "a"})
+	d.reportSkip(sponsorblock.Segment{})
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, []string{"a"}, getReported())
+	}, time.Second, 10*time.Millisecond)
+
+	d.reportSkip(sponsorblock.Segment{UUID: "a"})
+	d.reportSkip(sponsorblock.Segment{UUID: "b"})
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, []string{"a", "b"}, getReported())
+	}, time.Second, 10*time.Millisecond)
+
+	// A new video session reports segments again
+	d.reported = nil
+	d.reportSkip(sponsorblock.Segment{UUID: "a"})
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, []string{"a", "b", "a"}, getReported())
+	}, time.Second, 10*time.Millisecond)
+}
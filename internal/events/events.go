@@ -0,0 +1,41 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+const maxEvents = 100
+
+type Event struct {
+	Time    time.Time
+	Device  string
+	Message string
+}
+
+//nolint:gochecknoglobals
+var (
+	events []Event
+	mu     sync.Mutex
+)
+
+func Add(device, message string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	events = append(events, Event{Time: time.Now(), Device: device, Message: message})
+	if len(events) > maxEvents {
+		events = events[len(events)-maxEvents:]
+	}
+}
+
+// Recent returns up to n of the newest events, oldest first.
+func Recent(n int) []Event {
+	mu.Lock()
+	defer mu.Unlock()
+
+	n = min(n, len(events))
+	result := make([]Event, n)
+	copy(result, events[len(events)-n:])
+	return result
+}
@@ -0,0 +1,25 @@
+package events
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecent(t *testing.T) {
+	t.Cleanup(func() { events = nil })
+
+	assert.Empty(t, Recent(10))
+
+	for i := range maxEvents + 5 {
+		Add("device", strconv.Itoa(i))
+	}
+
+	got := Recent(3)
+	require.Len(t, got, 3)
+	assert.Equal(t, strconv.Itoa(maxEvents+2), got[0].Message)
+	assert.Equal(t, strconv.Itoa(maxEvents+4), got[2].Message)
+	assert.Len(t, Recent(1000), maxEvents)
+}
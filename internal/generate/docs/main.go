@@ -52,7 +52,7 @@ func generateFlagDoc(cmd *cobra.Command, output string) error {
 }
 
 func generateEnvDoc(cmd *cobra.Command, output string) error {
-	excludeNames := []string{"completion", names.FlagConfig, "help", "version"}
+	excludeNames := []string{"completion", names.FlagConfig, names.FlagTUI, "help", "version"}
 	var rows []table.Row
 	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
 		if slices.Contains(excludeNames, flag.Name) {
@@ -0,0 +1,93 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+
+	"gabe565.com/castsponsorskip/internal/util"
+)
+
+// MaxEntries is the number of segments kept in the history file.
+const MaxEntries = 100
+
+// Entry is a segment that a device skipped or muted.
+type Entry struct {
+	Time     time.Time  `json:"time"`
+	Device   string     `json:"device"`
+	VideoID  string     `json:"videoID"`
+	UUID     string     `json:"UUID"`
+	Category string     `json:"category"`
+	Action   string     `json:"action"`
+	Segment  [2]float32 `json:"segment"`
+	// SeekBacks counts how often playback returned to the segment after it was skipped.
+	SeekBacks int `json:"seekBacks,omitempty"`
+}
+
+type historyFile struct {
+	Entries []Entry `json:"entries"`
+}
+
+//nolint:gochecknoglobals
+var mu sync.Mutex
+
+// Load returns the entries in the history file, oldest first. A missing file is not an error.
+func Load(path string) ([]Entry, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	return load(path)
+}
+
+func load(path string) ([]Entry, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var f historyFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse segment history %q: %w", path, err)
+	}
+	return f.Entries, nil
+}
+
+// Add appends an entry to the history file.
+// An entry for the same segment on the same device replaces the previous one.
+// The oldest entries are removed once there are more than MaxEntries.
+func Add(path string, entry Entry) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	entries, err := load(path)
+	if err != nil {
+		return err
+	}
+
+	for i, e := range entries {
+		if e.UUID == entry.UUID && e.Device == entry.Device {
+			entries = append(entries[:i], entries[i+1:]...)
+			break
+		}
+	}
+	entries = append(entries, entry)
+	if len(entries) > MaxEntries {
+		entries = entries[len(entries)-MaxEntries:]
+	}
+	return save(path, entries)
+}
+
+// save writes the history file atomically. The caller must hold mu.
+func save(path string, entries []Entry) error {
+	b, err := json.Marshal(historyFile{Entries: entries})
+	if err != nil {
+		return err
+	}
+	return util.WriteFileAtomic(path, b)
+}
@@ -0,0 +1,74 @@
+package history
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history", "segments.json")
+
+	entries, err := Load(path)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	now := time.Now().Truncate(time.Second)
+	for i := range MaxEntries + 5 {
+		require.NoError(t, Add(path, Entry{
+			Time:     now.Add(time.Duration(i) * time.Second),
+			Device:   "Living Room TV",
+			UUID:     strconv.Itoa(i),
+			Category: "sponsor",
+			Action:   "skip",
+		}))
+	}
+
+	entries, err = Load(path)
+	require.NoError(t, err)
+	require.Len(t, entries, MaxEntries)
+	assert.Equal(t, "5", entries[0].UUID)
+	assert.Equal(t, strconv.Itoa(MaxEntries+4), entries[len(entries)-1].UUID)
+
+	// Acting on a segment again moves it to the end
+	require.NoError(t, Add(path, Entry{Device: "Living Room TV", UUID: "5", SeekBacks: 2}))
+	entries, err = Load(path)
+	require.NoError(t, err)
+	require.Len(t, entries, MaxEntries)
+	assert.Equal(t, "6", entries[0].UUID)
+	assert.Equal(t, "5", entries[len(entries)-1].UUID)
+	assert.Equal(t, 2, entries[len(entries)-1].SeekBacks)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "segments.json")
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+
+	_, err := Load(path)
+	require.Error(t, err)
+	require.Error(t, Add(path, Entry{UUID: "1"}))
+}
+
+func TestRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "segments.json")
+	recorder := NewRecorder(path)
+	ctx, cancel := context.WithCancel(t.Context())
+	go recorder.Run(ctx)
+
+	for i := range 3 {
+		recorder.Record(Entry{Device: "TV", UUID: strconv.Itoa(i)})
+	}
+	cancel()
+	recorder.Wait()
+
+	entries, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 3, "queued entries should be written before Run returns")
+	assert.Equal(t, "2", entries[2].UUID)
+}
@@ -0,0 +1,65 @@
+package history
+
+import (
+	"context"
+
+	"gabe565.com/castsponsorskip/internal/config"
+)
+
+const recordQueueSize = 64
+
+// Recorder adds entries to the history file in the background, so device ticks don't wait on disk writes.
+type Recorder struct {
+	path  string
+	queue chan Entry
+	done  chan struct{}
+}
+
+func NewRecorder(path string) *Recorder {
+	return &Recorder{
+		path:  path,
+		queue: make(chan Entry, recordQueueSize),
+		done:  make(chan struct{}),
+	}
+}
+
+// Record queues an entry. It never blocks; entries are dropped if the queue is full.
+func (r *Recorder) Record(entry Entry) {
+	select {
+	case r.queue <- entry:
+	default:
+		config.Logger(config.SubsystemTick).Debug("Segment history queue is full. Dropping entry.", "uuid", entry.UUID)
+	}
+}
+
+// Run writes queued entries until ctx is canceled, then writes the remaining entries.
+func (r *Recorder) Run(ctx context.Context) {
+	defer close(r.done)
+
+	for {
+		select {
+		case entry := <-r.queue:
+			r.add(entry)
+		case <-ctx.Done():
+			for {
+				select {
+				case entry := <-r.queue:
+					r.add(entry)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// Wait blocks until Run has written the remaining entries.
+func (r *Recorder) Wait() {
+	<-r.done
+}
+
+func (r *Recorder) add(entry Entry) {
+	if err := Add(r.path, entry); err != nil {
+		config.Logger(config.SubsystemTick).Warn("Failed to save segment history.", "error", err.Error())
+	}
+}
@@ -0,0 +1,116 @@
+package logsink
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/coreos/go-systemd/v22/journal"
+)
+
+var ErrJournaldUnavailable = errors.New("journald is not available")
+
+//nolint:gochecknoglobals
+var (
+	journalEnabled = journal.Enabled
+	journalSend    = journal.Send
+)
+
+// journaldHandler sends records to the systemd journal.
+// Attributes become journal fields, so "video_id" can be queried with journalctl VIDEO_ID=...
+type journaldHandler struct {
+	level  slog.Leveler
+	tag    string
+	fields map[string]string
+	prefix string
+}
+
+func newJournaldHandler(spec Spec) *journaldHandler {
+	return &journaldHandler{
+		level:  spec.Level,
+		tag:    spec.Tag,
+		fields: map[string]string{},
+	}
+}
+
+func (h *journaldHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *journaldHandler) Handle(_ context.Context, r slog.Record) error {
+	fields := make(map[string]string, len(h.fields)+r.NumAttrs()+1)
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	r.Attrs(func(attr slog.Attr) bool {
+		addField(fields, h.prefix, attr)
+		return true
+	})
+	fields["SYSLOG_IDENTIFIER"] = h.tag
+
+	return journalSend(r.Message, journal.Priority(severity(r.Level)), fields)
+}
+
+func (h *journaldHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handler := *h
+	handler.fields = make(map[string]string, len(h.fields)+len(attrs))
+	for k, v := range h.fields {
+		handler.fields[k] = v
+	}
+	for _, attr := range attrs {
+		addField(handler.fields, h.prefix, attr)
+	}
+	return &handler
+}
+
+func (h *journaldHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	handler := *h
+	handler.prefix += name + "_"
+	return &handler
+}
+
+func addField(fields map[string]string, prefix string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+
+	if attr.Value.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if attr.Key != "" {
+			groupPrefix += attr.Key + "_"
+		}
+		for _, sub := range attr.Value.Group() {
+			addField(fields, groupPrefix, sub)
+		}
+		return
+	}
+
+	if name := fieldName(prefix + attr.Key); name != "" {
+		fields[name] = attr.Value.String()
+	}
+}
+
+// fieldName converts an attribute key to a valid journal field name.
+// Journal fields may only contain uppercase letters, digits and underscores, and may not start with an underscore.
+func fieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+	name = strings.TrimLeft(name, "_")
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "F_" + name
+	}
+	return name
+}
@@ -0,0 +1,62 @@
+package logsink
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/coreos/go-systemd/v22/journal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_fieldName(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"device", "DEVICE"},
+		{"video_id", "VIDEO_ID"},
+		{"segment.category", "SEGMENT_CATEGORY"},
+		{"_private", "PRIVATE"},
+		{"1st", "F_1ST"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			assert.Equal(t, tt.want, fieldName(tt.key))
+		})
+	}
+}
+
+func TestJournaldHandler(t *testing.T) {
+	var (
+		gotMessage  string
+		gotPriority journal.Priority
+		gotFields   map[string]string
+	)
+	prevEnabled, prevSend := journalEnabled, journalSend
+	t.Cleanup(func() { journalEnabled, journalSend = prevEnabled, prevSend })
+	journalEnabled = func() bool { return true }
+	journalSend = func(message string, priority journal.Priority, vars map[string]string) error {
+		gotMessage, gotPriority, gotFields = message, priority, vars
+		return nil
+	}
+
+	spec, err := Parse("journald://?level=debug")
+	require.NoError(t, err)
+	handler, closer, err := spec.Open()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = closer.Close() })
+
+	slog.New(handler).With("device", "Living Room").
+		Info("Skipping to timestamp.", "video_id", "dQw4w9WgXcQ", slog.Group("segment", "category", "sponsor"))
+
+	assert.Equal(t, "Skipping to timestamp.", gotMessage)
+	assert.Equal(t, journal.PriInfo, gotPriority)
+	assert.Equal(t, map[string]string{
+		"DEVICE":            "Living Room",
+		"VIDEO_ID":          "dQw4w9WgXcQ",
+		"SEGMENT_CATEGORY":  "sponsor",
+		"SYSLOG_IDENTIFIER": DefaultTag,
+	}, gotFields)
+}
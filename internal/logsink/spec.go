@@ -0,0 +1,217 @@
+package logsink
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lmittmann/tint"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+var ErrInvalidSink = errors.New("invalid log sink")
+
+const (
+	TypeFile     = "file"
+	TypeSyslog   = "syslog"
+	TypeJournald = "journald"
+
+	FormatPlain = "plain"
+	FormatJSON  = "json"
+
+	DefaultTag = "castsponsorskip"
+)
+
+// Spec describes a log destination.
+// Specs are written as URLs:
+//
+//	file:///var/log/castsponsorskip.log?level=debug&max-size=10&max-age=7&max-backups=3&compress=true&format=json
+//	syslog+udp://localhost:514?level=warn&facility=local0&tag=castsponsorskip
+//	syslog+tcp://logs.example.com:601
+//	syslog+unix:///dev/log
+//	journald://?level=info
+type Spec struct {
+	raw string
+
+	Type    string
+	Network string
+	Address string
+	Level   slog.Level
+
+	Format     string
+	MaxSize    int
+	MaxAge     int
+	MaxBackups int
+	Compress   bool
+
+	Tag      string
+	Facility int
+}
+
+func Parse(s string) (Spec, error) {
+	s = strings.TrimSpace(s)
+	spec := Spec{raw: s, Level: slog.LevelInfo}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return spec, fmt.Errorf("%w: %q: %w", ErrInvalidSink, s, err)
+	}
+
+	allowed := []string{"level"}
+	switch scheme, network, _ := strings.Cut(u.Scheme, "+"); scheme {
+	case TypeFile:
+		spec.Type = TypeFile
+		spec.Format = FormatPlain
+		if u.Opaque != "" {
+			spec.Address = u.Opaque
+		} else {
+			spec.Address = u.Host + u.Path
+		}
+		if spec.Address == "" {
+			return spec, fmt.Errorf("%w: %q: missing path", ErrInvalidSink, s)
+		}
+		allowed = append(allowed, "format", "max-size", "max-age", "max-backups", "compress")
+	case TypeSyslog:
+		spec.Type = TypeSyslog
+		spec.Tag = DefaultTag
+		spec.Facility = facilities["daemon"]
+		switch network {
+		case "", "udp", "tcp":
+			spec.Network = cmp.Or(network, "udp")
+			spec.Address = u.Host
+			if spec.Address == "" {
+				spec.Address = "localhost"
+			}
+			if u.Port() == "" {
+				spec.Address = net.JoinHostPort(strings.Trim(spec.Address, "[]"), "514")
+			}
+		case "unix":
+			spec.Network = network
+			spec.Address = cmp.Or(u.Path, "/dev/log")
+		default:
+			return spec, fmt.Errorf("%w: %q: unsupported syslog network %q", ErrInvalidSink, s, network)
+		}
+		allowed = append(allowed, "tag", "facility")
+	case TypeJournald:
+		spec.Type = TypeJournald
+		spec.Tag = DefaultTag
+		allowed = append(allowed, "tag")
+	default:
+		return spec, fmt.Errorf("%w: %q: unsupported type %q", ErrInvalidSink, s, u.Scheme)
+	}
+
+	query := u.Query()
+	for key := range query {
+		if !slices.Contains(allowed, key) {
+			return spec, fmt.Errorf("%w: %q: unknown option %q", ErrInvalidSink, s, key)
+		}
+	}
+
+	if v := query.Get("level"); v != "" {
+		if err := spec.Level.UnmarshalText([]byte(v)); err != nil {
+			return spec, fmt.Errorf("%w: %q: %w", ErrInvalidSink, s, err)
+		}
+	}
+
+	if v := query.Get("format"); v != "" {
+		if v != FormatPlain && v != FormatJSON {
+			return spec, fmt.Errorf("%w: %q: unsupported format %q", ErrInvalidSink, s, v)
+		}
+		spec.Format = v
+	}
+
+	for key, dst := range map[string]*int{
+		"max-size":    &spec.MaxSize,
+		"max-age":     &spec.MaxAge,
+		"max-backups": &spec.MaxBackups,
+	} {
+		if v := query.Get(key); v != "" {
+			if *dst, err = strconv.Atoi(v); err != nil || *dst < 0 {
+				return spec, fmt.Errorf("%w: %q: %s must be a non-negative integer", ErrInvalidSink, s, key)
+			}
+		}
+	}
+
+	if v := query.Get("compress"); v != "" {
+		if spec.Compress, err = strconv.ParseBool(v); err != nil {
+			return spec, fmt.Errorf("%w: %q: %w", ErrInvalidSink, s, err)
+		}
+	}
+
+	if v := query.Get("tag"); v != "" {
+		spec.Tag = v
+	}
+
+	if v := query.Get("facility"); v != "" {
+		facility, ok := facilities[strings.ToLower(v)]
+		if !ok {
+			return spec, fmt.Errorf("%w: %q: unknown facility %q", ErrInvalidSink, s, v)
+		}
+		spec.Facility = facility
+	}
+
+	return spec, nil
+}
+
+func (s Spec) String() string {
+	return s.raw
+}
+
+// Open creates a handler that writes to the destination.
+// The returned closer releases the destination's file or connection.
+func (s Spec) Open() (slog.Handler, io.Closer, error) {
+	switch s.Type {
+	case TypeFile:
+		w := &lumberjack.Logger{
+			Filename:   s.Address,
+			MaxSize:    s.MaxSize,
+			MaxAge:     s.MaxAge,
+			MaxBackups: s.MaxBackups,
+			Compress:   s.Compress,
+		}
+		if s.Format == FormatJSON {
+			return slog.NewJSONHandler(w, &slog.HandlerOptions{Level: s.Level}), w, nil
+		}
+		return tint.NewHandler(w, &tint.Options{
+			Level:      s.Level,
+			TimeFormat: time.DateTime,
+			NoColor:    true,
+		}), w, nil
+	case TypeSyslog:
+		w := newSyslogWriter(s.Network, s.Address)
+		if err := w.connect(); err != nil {
+			return nil, nil, err
+		}
+		go w.run()
+		return newSyslogHandler(w, s), w, nil
+	case TypeJournald:
+		if !journalEnabled() {
+			return nil, nil, fmt.Errorf("%w: %s", ErrJournaldUnavailable, s.raw)
+		}
+		return newJournaldHandler(s), io.NopCloser(nil), nil
+	default:
+		return nil, nil, fmt.Errorf("%w: %q", ErrInvalidSink, s.raw)
+	}
+}
+
+// severity converts a slog level to a syslog severity.
+func severity(level slog.Level) int {
+	switch {
+	case level >= slog.LevelError:
+		return 3
+	case level >= slog.LevelWarn:
+		return 4
+	case level >= slog.LevelInfo:
+		return 6
+	default:
+		return 7
+	}
+}
@@ -0,0 +1,103 @@
+package logsink
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    Spec
+		wantErr require.ErrorAssertionFunc
+	}{
+		{
+			"file",
+			"file:///var/log/castsponsorskip.log?level=debug&max-size=10&max-age=7&max-backups=3&compress=true&format=json",
+			Spec{
+				Type: TypeFile, Address: "/var/log/castsponsorskip.log", Level: slog.LevelDebug,
+				Format: FormatJSON, MaxSize: 10, MaxAge: 7, MaxBackups: 3, Compress: true,
+			},
+			require.NoError,
+		},
+		{
+			"relative file",
+			"file:castsponsorskip.log",
+			Spec{Type: TypeFile, Address: "castsponsorskip.log", Level: slog.LevelInfo, Format: FormatPlain},
+			require.NoError,
+		},
+		{
+			"syslog udp",
+			"syslog+udp://logs.example.com?level=warn&facility=local0",
+			Spec{
+				Type: TypeSyslog, Network: "udp", Address: "logs.example.com:514", Level: slog.LevelWarn,
+				Tag: DefaultTag, Facility: 16,
+			},
+			require.NoError,
+		},
+		{
+			"syslog tcp",
+			"syslog+tcp://[::1]:601?tag=css",
+			Spec{Type: TypeSyslog, Network: "tcp", Address: "[::1]:601", Level: slog.LevelInfo, Tag: "css", Facility: 3},
+			require.NoError,
+		},
+		{
+			"syslog unix",
+			"syslog+unix://",
+			Spec{Type: TypeSyslog, Network: "unix", Address: "/dev/log", Level: slog.LevelInfo, Tag: DefaultTag, Facility: 3},
+			require.NoError,
+		},
+		{
+			"journald",
+			"journald://?level=error",
+			Spec{Type: TypeJournald, Level: slog.LevelError, Tag: DefaultTag},
+			require.NoError,
+		},
+		{"unknown type", "kafka://localhost", Spec{}, require.Error},
+		{"unknown network", "syslog+quic://localhost", Spec{}, require.Error},
+		{"missing path", "file://", Spec{}, require.Error},
+		{"unknown option", "journald://?max-size=1", Spec{}, require.Error},
+		{"invalid level", "journald://?level=loud", Spec{}, require.Error},
+		{"invalid format", "file:///tmp/log?format=xml", Spec{}, require.Error},
+		{"invalid size", "file:///tmp/log?max-size=-1", Spec{}, require.Error},
+		{"invalid facility", "syslog://?facility=web", Spec{}, require.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.s)
+			tt.wantErr(t, err)
+			if err != nil {
+				require.ErrorIs(t, err, ErrInvalidSink)
+				return
+			}
+			tt.want.raw = tt.s
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSpec_OpenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "castsponsorskip.log")
+	spec, err := Parse("file://" + path + "?level=warn&format=json")
+	require.NoError(t, err)
+
+	handler, closer, err := spec.Open()
+	require.NoError(t, err)
+	logger := slog.New(handler)
+	logger.Info("hidden")
+	logger.Warn("shown", "device", "Living Room")
+	require.NoError(t, closer.Close())
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), "hidden")
+	assert.Contains(t, string(b), `"msg":"shown","device":"Living Room"`)
+	assert.False(t, handler.Enabled(context.Background(), slog.LevelInfo))
+}
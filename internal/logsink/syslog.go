@@ -0,0 +1,246 @@
+package logsink
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//nolint:gochecknoglobals
+var facilities = map[string]int{
+	"kern":     0,
+	"user":     1,
+	"mail":     2,
+	"daemon":   3,
+	"auth":     4,
+	"syslog":   5,
+	"lpr":      6,
+	"news":     7,
+	"uucp":     8,
+	"cron":     9,
+	"authpriv": 10,
+	"ftp":      11,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+const (
+	syslogQueueSize    = 1024
+	syslogDialTimeout  = 5 * time.Second
+	syslogWriteTimeout = 5 * time.Second
+	syslogMinBackoff   = time.Second
+	syslogMaxBackoff   = time.Minute
+)
+
+// syslogWriter sends messages from a background goroutine, so a slow or unreachable collector never blocks logging.
+// Messages are dropped while the queue is full or the collector is unreachable.
+type syslogWriter struct {
+	network string
+	addr    string
+
+	mu     sync.Mutex
+	queue  chan []byte
+	closed bool
+	done   chan struct{}
+
+	// The fields below are owned by run once it starts.
+	conn    net.Conn
+	backoff time.Duration
+	retryAt time.Time
+}
+
+func newSyslogWriter(network, addr string) *syslogWriter {
+	return &syslogWriter{
+		network: network,
+		addr:    addr,
+		queue:   make(chan []byte, syslogQueueSize),
+		done:    make(chan struct{}),
+	}
+}
+
+func (w *syslogWriter) connect() error {
+	if w.conn != nil {
+		_ = w.conn.Close()
+		w.conn = nil
+	}
+
+	network := w.network
+	if network == "unix" {
+		// Local syslog daemons usually listen on a datagram socket.
+		if conn, err := net.Dial("unixgram", w.addr); err == nil {
+			w.conn = conn
+			return nil
+		}
+	}
+
+	conn, err := net.DialTimeout(network, w.addr, syslogDialTimeout)
+	if err != nil {
+		return err
+	}
+	w.conn = conn
+	return nil
+}
+
+// write queues a message. It never blocks; the message is dropped if the queue is full.
+func (w *syslogWriter) write(msg []byte) {
+	if w.network == "tcp" {
+		// RFC 6587 octet counting
+		msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	select {
+	case w.queue <- msg:
+	default:
+	}
+}
+
+// run sends queued messages until the writer is closed.
+func (w *syslogWriter) run() {
+	defer close(w.done)
+	for msg := range w.queue {
+		w.send(msg)
+	}
+	if w.conn != nil {
+		_ = w.conn.Close()
+	}
+}
+
+// send writes a message, reconnecting once if the connection was lost.
+// After a failed dial, the message is dropped and dialing is retried with exponential backoff.
+func (w *syslogWriter) send(msg []byte) {
+	for range 2 {
+		if w.conn == nil {
+			if time.Now().Before(w.retryAt) {
+				return
+			}
+			if err := w.connect(); err != nil {
+				w.backoff = min(max(2*w.backoff, syslogMinBackoff), syslogMaxBackoff)
+				w.retryAt = time.Now().Add(w.backoff)
+				return
+			}
+			w.backoff = 0
+		}
+
+		_ = w.conn.SetWriteDeadline(time.Now().Add(syslogWriteTimeout))
+		if _, err := w.conn.Write(msg); err == nil {
+			return
+		}
+		_ = w.conn.Close()
+		w.conn = nil
+	}
+}
+
+// Close sends the queued messages and closes the connection.
+func (w *syslogWriter) Close() error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+	<-w.done
+	return nil
+}
+
+// syslogHandler formats records as RFC 5424 messages.
+// Attributes are appended to the message in logfmt.
+type syslogHandler struct {
+	w        *syslogWriter
+	level    slog.Leveler
+	facility int
+	hostname string
+	tag      string
+	pid      string
+
+	mu   *sync.Mutex
+	buf  *bytes.Buffer
+	text slog.Handler
+}
+
+func newSyslogHandler(w *syslogWriter, spec Spec) *syslogHandler {
+	hostname, _ := os.Hostname()
+	buf := &bytes.Buffer{}
+	return &syslogHandler{
+		w:        w,
+		level:    spec.Level,
+		facility: spec.Facility,
+		hostname: cmp.Or(hostname, "-"),
+		tag:      spec.Tag,
+		pid:      strconv.Itoa(os.Getpid()),
+		mu:       &sync.Mutex{},
+		buf:      buf,
+		text: slog.NewTextHandler(buf, &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if len(groups) == 0 {
+					switch a.Key {
+					case slog.TimeKey, slog.LevelKey, slog.MessageKey:
+						return slog.Attr{}
+					}
+				}
+				return a
+			},
+		}),
+	}
+}
+
+func (h *syslogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *syslogHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.mu.Lock()
+	h.buf.Reset()
+	err := h.text.Handle(ctx, r)
+	attrs := strings.TrimSpace(h.buf.String())
+	h.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+
+	var msg strings.Builder
+	msg.WriteString("<" + strconv.Itoa(h.facility*8+severity(r.Level)) + ">1 ")
+	msg.WriteString(t.Format("2006-01-02T15:04:05.000000Z07:00") + " ")
+	msg.WriteString(h.hostname + " " + h.tag + " " + h.pid + " - - ")
+	msg.WriteString(r.Message)
+	if attrs != "" {
+		msg.WriteString(" " + attrs)
+	}
+	h.w.write([]byte(msg.String()))
+	return nil
+}
+
+func (h *syslogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handler := *h
+	handler.text = h.text.WithAttrs(attrs)
+	return &handler
+}
+
+func (h *syslogHandler) WithGroup(name string) slog.Handler {
+	handler := *h
+	handler.text = h.text.WithGroup(name)
+	return &handler
+}
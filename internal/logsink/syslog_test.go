@@ -0,0 +1,92 @@
+package logsink
+
+import (
+	"bufio"
+	"log/slog"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyslogHandler_UDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	spec, err := Parse("syslog+udp://" + conn.LocalAddr().String() + "?facility=local0&tag=css")
+	require.NoError(t, err)
+
+	handler, closer, err := spec.Open()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = closer.Close() })
+
+	logger := slog.New(handler).With("device", "Living Room")
+	logger.Debug("hidden")
+	logger.WithGroup("segment").Warn("Failed to seek.", "category", "sponsor")
+
+	buf := make([]byte, 1024)
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+
+	hostname, _ := os.Hostname()
+	want := regexp.MustCompile(`^<132>1 \S+ ` + regexp.QuoteMeta(hostname) + ` css ` + strconv.Itoa(os.Getpid()) +
+		` - - Failed to seek\. device="Living Room" segment\.category=sponsor$`)
+	assert.Regexp(t, want, string(buf[:n]))
+}
+
+func TestSyslogHandler_TCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		received <- line
+	}()
+
+	spec, err := Parse("syslog+tcp://" + listener.Addr().String())
+	require.NoError(t, err)
+
+	handler, closer, err := spec.Open()
+	require.NoError(t, err)
+	slog.New(handler).Error("first")
+	slog.New(handler).Error("second\n")
+	require.NoError(t, closer.Close())
+
+	line := <-received
+	assert.Regexp(t, `^\d+ <27>1 .* - - first\d+ <27>1 .* - - second\n$`, line)
+}
+
+func TestSyslogWriter_Unreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	w := newSyslogWriter("tcp", addr)
+	go w.run()
+
+	// Logging never waits on the collector, even once the queue is full
+	start := time.Now()
+	for range 2 * syslogQueueSize {
+		w.write([]byte("message"))
+	}
+	require.NoError(t, w.Close())
+	assert.Less(t, time.Since(start), syslogDialTimeout)
+	assert.Equal(t, syslogMinBackoff, w.backoff, "dialing should back off instead of retrying every message")
+
+	// Writes after Close are dropped
+	w.write([]byte("late"))
+}
@@ -0,0 +1,308 @@
+package sponsorblock
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var ErrMissingColumn = errors.New("missing column")
+
+// DefaultMinVotes matches the public API, which hides segments with 2 or more net downvotes.
+const DefaultMinVotes = -1
+
+type ImportOptions struct {
+	// MinVotes is the lowest vote count a segment needs to be imported.
+	MinVotes int
+}
+
+type ImportStats struct {
+	Rows     int
+	Segments int
+	Videos   int
+	// Hidden counts rows the public API would not return.
+	Hidden  int
+	Invalid int
+}
+
+// Import reads a sponsorTimes.csv database dump and writes an index of the visible segments to path.
+// Rows that are hidden, shadow hidden, downvoted below opts.MinVotes, or for other services are skipped.
+// The file is replaced atomically, so a running process can keep using the previous index until it is done.
+func Import(r io.Reader, path string, opts ImportOptions) (ImportStats, error) {
+	var stats ImportStats
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return stats, err
+	}
+
+	reader := csv.NewReader(bufio.NewReaderSize(r, 1<<20))
+	reader.ReuseRecord = true
+	reader.LazyQuotes = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return stats, err
+	}
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		cols[strings.TrimSpace(name)] = i
+	}
+	for _, name := range []string{"videoID", "startTime", "endTime", "votes", "UUID", "category"} {
+		if _, ok := cols[name]; !ok {
+			return stats, fmt.Errorf("%w: %q", ErrMissingColumn, name)
+		}
+	}
+
+	// Rows are sorted on disk, so the dump doesn't need to fit in memory
+	tmpDir, err := os.MkdirTemp(filepath.Dir(path), filepath.Base(path)+".import-*")
+	if err != nil {
+		return stats, err
+	}
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	sorter := &rowSorter{dir: tmpDir}
+	defer sorter.close()
+
+	for {
+		record, err := reader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				stats.Rows++
+				stats.Invalid++
+				continue
+			}
+			return stats, err
+		}
+		stats.Rows++
+
+		if len(record) != len(header) {
+			stats.Invalid++
+			continue
+		}
+		field := func(name string) string {
+			if i, ok := cols[name]; ok {
+				return record[i]
+			}
+			return ""
+		}
+
+		segment, ok := parseRow(field)
+		switch {
+		case !ok, field("videoID") == "":
+			stats.Invalid++
+			continue
+		case !visible(field, segment, opts):
+			stats.Hidden++
+			continue
+		}
+
+		if err := sorter.add(field("videoID"), segment); err != nil {
+			return stats, err
+		}
+		stats.Segments++
+	}
+
+	stats.Videos, err = writeDB(path, sorter)
+	return stats, err
+}
+
+// parseRow parses the segment in a row. Fields share memory with the reused CSV record,
+// so the segment is only valid until the next row is read.
+func parseRow(field func(string) string) (Segment, bool) {
+	start, err := strconv.ParseFloat(field("startTime"), 32)
+	if err != nil {
+		return Segment{}, false
+	}
+	end, err := strconv.ParseFloat(field("endTime"), 32)
+	if err != nil {
+		return Segment{}, false
+	}
+	votes, err := strconv.Atoi(field("votes"))
+	if err != nil {
+		return Segment{}, false
+	}
+
+	segment := Segment{
+		Segment:     [2]float32{float32(start), float32(end)},
+		UUID:        field("UUID"),
+		Category:    field("category"),
+		ActionType:  field("actionType"),
+		Votes:       votes,
+		Description: field("description"),
+	}
+	if segment.ActionType == "" {
+		// Dumps from before action types were added only contain skips
+		segment.ActionType = ActionTypeSkip
+	}
+	if duration, err := strconv.ParseFloat(field("videoDuration"), 32); err == nil {
+		segment.VideoDuration = float32(duration)
+	}
+	if locked, err := strconv.Atoi(field("locked")); err == nil {
+		segment.Locked = locked
+	}
+	return segment, true
+}
+
+// visible applies the rules the public API uses to hide segments.
+func visible(field func(string) string, segment Segment, opts ImportOptions) bool {
+	if service := field("service"); service != "" && service != "YouTube" {
+		return false
+	}
+	for _, name := range []string{"hidden", "shadowHidden"} {
+		if v := field(name); v != "" && v != "0" {
+			return false
+		}
+	}
+	return segment.Votes >= opts.MinVotes
+}
+
+// writeDB merges the sorted rows into videos and writes the database file atomically.
+// It returns the number of videos written.
+func writeDB(path string, sorter *rowSorter) (int, error) {
+	if err := sorter.flush(); err != nil {
+		return 0, err
+	}
+
+	// The index size is only known once every video is merged,
+	// so the index and segments are written to separate files first.
+	index, err := os.CreateTemp(sorter.dir, "index-*")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = index.Close()
+	}()
+	data, err := os.CreateTemp(sorter.dir, "data-*")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = data.Close()
+	}()
+
+	indexW, dataW := bufio.NewWriter(index), bufio.NewWriter(data)
+	var count int
+	var offset int64
+	var current importRow
+	var segments []json.RawMessage
+	writeVideo := func() error {
+		b, err := json.Marshal(struct {
+			VideoID  string            `json:"videoID"`
+			Segments []json.RawMessage `json:"segments"`
+		}{current.id, segments})
+		if err != nil {
+			return err
+		}
+		if _, err := dataW.Write(b); err != nil {
+			return err
+		}
+
+		var entry [dbIndexEntSize]byte
+		copy(entry[:sha256.Size], current.hash[:])
+		binary.BigEndian.PutUint64(entry[sha256.Size:], uint64(offset))   //nolint:gosec
+		binary.BigEndian.PutUint32(entry[sha256.Size+8:], uint32(len(b))) //nolint:gosec
+		if _, err := indexW.Write(entry[:]); err != nil {
+			return err
+		}
+		offset += int64(len(b))
+		count++
+		return nil
+	}
+
+	err = sorter.merge(func(row importRow) error {
+		if len(segments) != 0 && row.hash != current.hash {
+			if err := writeVideo(); err != nil {
+				return err
+			}
+			segments = segments[:0]
+		}
+		if len(segments) == 0 {
+			current = row
+		}
+		segments = append(segments, row.data)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(segments) != 0 {
+		if err := writeVideo(); err != nil {
+			return 0, err
+		}
+	}
+	if err := indexW.Flush(); err != nil {
+		return 0, err
+	}
+	if err := dataW.Flush(); err != nil {
+		return 0, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	w := bufio.NewWriter(tmp)
+	header := make([]byte, dbHeaderSize)
+	copy(header, dbMagic)
+	binary.BigEndian.PutUint32(header[len(dbMagic):], uint32(count)) //nolint:gosec
+	if _, err := w.Write(header); err != nil {
+		return 0, err
+	}
+
+	// Offsets are relative to the segments until the index size is known
+	dataStart := int64(dbHeaderSize + count*dbIndexEntSize)
+	if _, err := index.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	indexR := bufio.NewReader(index)
+	var entry [dbIndexEntSize]byte
+	for range count {
+		if _, err := io.ReadFull(indexR, entry[:]); err != nil {
+			return 0, err
+		}
+		offset := binary.BigEndian.Uint64(entry[sha256.Size:])
+		binary.BigEndian.PutUint64(entry[sha256.Size:], offset+uint64(dataStart)) //nolint:gosec
+		if _, err := w.Write(entry[:]); err != nil {
+			return 0, err
+		}
+	}
+
+	if _, err := data.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	if _, err := io.Copy(w, data); err != nil {
+		return 0, err
+	}
+	if err := w.Flush(); err != nil {
+		return 0, err
+	}
+
+	// The daemon may run as another user
+	if err := tmp.Chmod(0o644); err != nil {
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+	return count, os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,193 @@
+package sponsorblock
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strings"
+)
+
+// importRunSize is the approximate number of bytes of rows sorted in memory before they are written to a run file.
+//
+//nolint:gochecknoglobals
+var importRunSize = 64 << 20
+
+// importRowOverhead approximates the memory used by an importRow besides its ID and data.
+const importRowOverhead = 96
+
+// importRow is a visible segment keyed by the hash of its video ID.
+type importRow struct {
+	hash  [sha256.Size]byte
+	id    string
+	start float32
+	// data is the JSON encoded segment.
+	data []byte
+}
+
+func compareRows(a, b importRow) int {
+	if c := bytes.Compare(a.hash[:], b.hash[:]); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.start, b.start)
+}
+
+// rowSorter sorts import rows by video ID hash and segment start.
+// Rows are sorted in memory in batches of importRunSize, which are written to run files in dir and merged.
+type rowSorter struct {
+	dir  string
+	rows []importRow
+	size int
+	runs []*os.File
+}
+
+// add buffers a segment. The ID is cloned, since it may share memory with the CSV record.
+func (s *rowSorter) add(id string, segment Segment) error {
+	data, err := json.Marshal(segment)
+	if err != nil {
+		return err
+	}
+
+	s.rows = append(s.rows, importRow{
+		hash:  sha256.Sum256([]byte(id)),
+		id:    strings.Clone(id),
+		start: segment.Segment[0],
+		data:  data,
+	})
+	s.size += len(id) + len(data) + importRowOverhead
+	if s.size >= importRunSize {
+		return s.flush()
+	}
+	return nil
+}
+
+// flush sorts the buffered rows and writes them to a new run file.
+func (s *rowSorter) flush() error {
+	if len(s.rows) == 0 {
+		return nil
+	}
+
+	slices.SortStableFunc(s.rows, compareRows)
+
+	f, err := os.CreateTemp(s.dir, "run-*")
+	if err != nil {
+		return err
+	}
+	s.runs = append(s.runs, f)
+
+	w := bufio.NewWriter(f)
+	for _, row := range s.rows {
+		if err := writeRow(w, row); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	clear(s.rows)
+	s.rows, s.size = s.rows[:0], 0
+	return nil
+}
+
+// merge calls fn with every row in sorted order.
+func (s *rowSorter) merge(fn func(row importRow) error) error {
+	type run struct {
+		r   *bufio.Reader
+		row importRow
+	}
+
+	runs := make([]*run, 0, len(s.runs))
+	for _, f := range s.runs {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		r := &run{r: bufio.NewReader(f)}
+		row, err := readRow(r.r)
+		switch {
+		case errors.Is(err, io.EOF):
+			continue
+		case err != nil:
+			return err
+		}
+		r.row = row
+		runs = append(runs, r)
+	}
+
+	// There are few runs, so the next row is found with a linear scan.
+	// Earlier runs win ties to keep rows in their original order.
+	for len(runs) != 0 {
+		next := 0
+		for i := 1; i < len(runs); i++ {
+			if compareRows(runs[i].row, runs[next].row) < 0 {
+				next = i
+			}
+		}
+
+		r := runs[next]
+		if err := fn(r.row); err != nil {
+			return err
+		}
+
+		row, err := readRow(r.r)
+		switch {
+		case errors.Is(err, io.EOF):
+			runs = slices.Delete(runs, next, next+1)
+		case err != nil:
+			return err
+		default:
+			r.row = row
+		}
+	}
+	return nil
+}
+
+func (s *rowSorter) close() {
+	for _, f := range s.runs {
+		_ = f.Close()
+	}
+}
+
+// Rows are encoded as the video ID hash, segment start, ID length, data length, ID and data.
+const rowHeaderSize = sha256.Size + 4 + 4 + 4
+
+func writeRow(w io.Writer, row importRow) error {
+	var header [rowHeaderSize]byte
+	copy(header[:sha256.Size], row.hash[:])
+	binary.BigEndian.PutUint32(header[sha256.Size:], math.Float32bits(row.start))
+	binary.BigEndian.PutUint32(header[sha256.Size+4:], uint32(len(row.id)))   //nolint:gosec
+	binary.BigEndian.PutUint32(header[sha256.Size+8:], uint32(len(row.data))) //nolint:gosec
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, row.id); err != nil {
+		return err
+	}
+	_, err := w.Write(row.data)
+	return err
+}
+
+func readRow(r io.Reader) (importRow, error) {
+	var header [rowHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return importRow{}, err
+	}
+
+	var row importRow
+	copy(row.hash[:], header[:sha256.Size])
+	row.start = math.Float32frombits(binary.BigEndian.Uint32(header[sha256.Size:]))
+	idLen := binary.BigEndian.Uint32(header[sha256.Size+4:])
+	b := make([]byte, idLen+binary.BigEndian.Uint32(header[sha256.Size+8:]))
+	if _, err := io.ReadFull(r, b); err != nil {
+		return importRow{}, err
+	}
+	row.id, row.data = string(b[:idLen]), b[idLen:]
+	return row, nil
+}
@@ -0,0 +1,164 @@
+package sponsorblock
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDump = `videoID,startTime,endTime,votes,locked,incorrectVotes,UUID,userID,timeSubmitted,views,category,actionType,service,videoDuration,hidden,reputation,shadowHidden,hashedVideoID,userAgent,description
+dQw4w9WgXcQ,30.5,40,3,1,1,uuid-b,user,0,10,sponsor,skip,YouTube,212,0,0,0,hash,agent,
+dQw4w9WgXcQ,1,2,0,0,1,uuid-a,user,0,10,selfpromo,mute,YouTube,212,0,0,0,hash,agent,"with, comma"
+dQw4w9WgXcQ,50,60,-1,0,1,uuid-c,user,0,10,sponsor,skip,YouTube,212,0,0,0,hash,agent,
+dQw4w9WgXcQ,70,80,-2,0,1,downvoted,user,0,10,sponsor,skip,YouTube,212,0,0,0,hash,agent,
+dQw4w9WgXcQ,70,80,0,0,1,hidden,user,0,10,sponsor,skip,YouTube,212,1,0,0,hash,agent,
+dQw4w9WgXcQ,70,80,0,0,1,shadow-hidden,user,0,10,sponsor,skip,YouTube,212,0,0,1,hash,agent,
+dQw4w9WgXcQ,70,80,0,0,1,other-service,user,0,10,sponsor,skip,PeerTube,212,0,0,0,hash,agent,
+y8Kyi0WNg40,10,20,0,0,1,uuid-d,user,0,10,sponsor,skip,YouTube,100,0,0,0,hash,agent,
+y8Kyi0WNg40,abc,20,0,0,1,invalid,user,0,10,sponsor,skip,YouTube,100,0,0,0,hash,agent,
+short,row
+`
+
+func importTestDump(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "sponsorblock.db")
+	stats, err := Import(strings.NewReader(testDump), path, ImportOptions{MinVotes: DefaultMinVotes})
+	require.NoError(t, err)
+	assert.Equal(t, ImportStats{Rows: 10, Segments: 4, Videos: 2, Hidden: 4, Invalid: 2}, stats)
+	return path
+}
+
+func TestImport(t *testing.T) {
+	db, err := OpenDB(importTestDump(t))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	assert.Equal(t, 2, db.Len())
+
+	segments, found, err := db.Lookup("dQw4w9WgXcQ")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, []Segment{
+		{
+			Segment:       [2]float32{1, 2},
+			UUID:          "uuid-a",
+			Category:      "selfpromo",
+			VideoDuration: 212,
+			ActionType:    ActionTypeMute,
+			Description:   "with, comma",
+		},
+		{
+			Segment:       [2]float32{30.5, 40},
+			UUID:          "uuid-b",
+			Category:      "sponsor",
+			VideoDuration: 212,
+			ActionType:    ActionTypeSkip,
+			Locked:        1,
+			Votes:         3,
+		},
+		{
+			Segment:       [2]float32{50, 60},
+			UUID:          "uuid-c",
+			Category:      "sponsor",
+			VideoDuration: 212,
+			ActionType:    ActionTypeSkip,
+			Votes:         -1,
+		},
+	}, segments)
+
+	segments, found, err = db.Lookup("y8Kyi0WNg40")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Len(t, segments, 1)
+	assert.Equal(t, "uuid-d", segments[0].UUID)
+
+	for _, id := range []string{"aaaaaaaaaaa", "zzzzzzzzzzz", "", "an-id-that-is-too-long"} {
+		_, found, err = db.Lookup(id)
+		require.NoError(t, err)
+		assert.False(t, found, id)
+	}
+}
+
+func TestImport_Runs(t *testing.T) {
+	want, err := os.ReadFile(importTestDump(t))
+	require.NoError(t, err)
+
+	defaultRunSize := importRunSize
+	t.Cleanup(func() {
+		importRunSize = defaultRunSize
+	})
+	importRunSize = 1
+
+	got, err := os.ReadFile(importTestDump(t))
+	require.NoError(t, err)
+	assert.Equal(t, want, got, "merging one run per row should match sorting in memory")
+
+	entries, err := os.ReadDir(filepath.Dir(importTestDump(t)))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary files should be removed")
+}
+
+func TestImport_NewDir(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "castsponsorskip", "sponsorblock.db")
+	_, err := Import(strings.NewReader(testDump), path, ImportOptions{MinVotes: DefaultMinVotes})
+	require.NoError(t, err)
+	assert.FileExists(t, path)
+}
+
+func TestImport_MissingColumn(t *testing.T) {
+	_, err := Import(strings.NewReader("videoID,startTime\n"), filepath.Join(t.TempDir(), "db"), ImportOptions{})
+	require.ErrorIs(t, err, ErrMissingColumn)
+}
+
+func TestOpenDB_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sponsorblock.db")
+	require.NoError(t, os.WriteFile(path, []byte("videoID,startTime,endTime\n"), 0o600))
+	_, err := OpenDB(path)
+	require.ErrorIs(t, err, ErrInvalidDB)
+}
+
+func TestQuerySegmentsLocal(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		_, _ = w.Write([]byte(`[{"videoID":"aaaaaaaaaaa","segments":[{"category":"sponsor","actionType":"skip","segment":[1,2],"UUID":"remote"}]}]`))
+	}))
+	t.Cleanup(server.Close)
+
+	conf := config.New()
+	conf.SponsorBlockLookup = config.LookupLocal
+	conf.SponsorBlockDBFile = importTestDump(t)
+	var err error
+	conf.SponsorBlockServer, err = url.Parse(server.URL)
+	require.NoError(t, err)
+
+	got, err := QuerySegments(t.Context(), conf, "dQw4w9WgXcQ")
+	require.NoError(t, err)
+	require.Len(t, got, 2, "segments should be filtered by category")
+	assert.Equal(t, "uuid-b", got[0].UUID)
+
+	got, err = QuerySegments(t.Context(), conf, "aaaaaaaaaaa")
+	require.NoError(t, err)
+	assert.Empty(t, got)
+	assert.Zero(t, requests, "server should not be queried without fallback")
+
+	conf.SponsorBlockDBFallback = true
+	got, err = QuerySegments(t.Context(), conf, "aaaaaaaaaaa")
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "remote", got[0].UUID)
+	assert.Equal(t, 1, requests)
+
+	conf.SponsorBlockDBFallback = false
+	conf.SponsorBlockDBFile = filepath.Join(t.TempDir(), "missing.db")
+	_, err = QuerySegments(t.Context(), conf, "dQw4w9WgXcQ")
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
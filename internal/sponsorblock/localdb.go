@@ -0,0 +1,176 @@
+package sponsorblock
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+var ErrInvalidDB = errors.New("invalid SponsorBlock database")
+
+// The database starts with a header, followed by an index of videos sorted by the SHA-256 hash of their ID,
+// followed by the JSON encoded segments of each video. Sorting by hash allows hash prefix lookups.
+const (
+	dbMagic        = "CSSSBDB2"
+	dbHeaderSize   = len(dbMagic) + 4    // Magic, video count
+	dbIndexEntSize = sha256.Size + 8 + 4 // Video ID hash, data offset, data length
+)
+
+// DB is a read-only local index of SponsorBlock segments created by Import.
+type DB struct {
+	f       *os.File
+	count   int
+	modTime time.Time
+}
+
+// OpenDB opens a database created by Import.
+func OpenDB(path string) (*DB, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	header := make([]byte, dbHeaderSize)
+	if _, err := f.ReadAt(header, 0); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("%w: %q: %w", ErrInvalidDB, path, err)
+	}
+	if string(header[:len(dbMagic)]) != dbMagic {
+		_ = f.Close()
+		return nil, fmt.Errorf("%w: %q: unknown format; run import-db again", ErrInvalidDB, path)
+	}
+
+	return &DB{
+		f:       f,
+		count:   int(binary.BigEndian.Uint32(header[len(dbMagic):])),
+		modTime: stat.ModTime(),
+	}, nil
+}
+
+// Len returns the number of videos in the database.
+func (db *DB) Len() int {
+	return db.count
+}
+
+// Lookup returns the segments of a video. The bool is false if the video is not in the database.
+func (db *DB) Lookup(id string) ([]Segment, bool, error) {
+	hash := sha256.Sum256([]byte(id))
+	i, err := db.search(hash[:])
+	if err != nil || i == db.count {
+		return nil, false, err
+	}
+
+	entry, err := db.readEntry(i)
+	if err != nil || !bytes.Equal(entry.hash, hash[:]) {
+		return nil, false, err
+	}
+
+	video, err := db.readVideo(entry)
+	if err != nil || video.VideoID != id {
+		return nil, false, err
+	}
+	return video.Segments, true, nil
+}
+
+// LookupPrefix returns every video whose ID hash starts with a hex encoded prefix.
+func (db *DB) LookupPrefix(prefix string) ([]Video, error) {
+	prefix = strings.ToLower(prefix)
+	// An odd length prefix is padded to find the first hash that could match
+	lower, err := hex.DecodeString(prefix + strings.Repeat("0", len(prefix)%2))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidHashPrefix, err)
+	}
+
+	i, err := db.search(lower)
+	if err != nil {
+		return nil, err
+	}
+
+	var videos []Video
+	for ; i < db.count; i++ {
+		entry, err := db.readEntry(i)
+		if err != nil {
+			return nil, err
+		}
+
+		hash := hex.EncodeToString(entry.hash)
+		if !strings.HasPrefix(hash, prefix) {
+			break
+		}
+
+		video, err := db.readVideo(entry)
+		if err != nil {
+			return nil, err
+		}
+		video.Hash = hash
+		videos = append(videos, video)
+	}
+	return videos, nil
+}
+
+type dbEntry struct {
+	hash   []byte
+	offset int64
+	length uint32
+}
+
+// search returns the index of the first entry with a hash greater than or equal to hash.
+// The index is binary searched without reading it into memory.
+func (db *DB) search(hash []byte) (int, error) {
+	lo, hi := 0, db.count
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		entry, err := db.readEntry(mid)
+		if err != nil {
+			return 0, err
+		}
+		if bytes.Compare(entry.hash, hash) < 0 {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo, nil
+}
+
+func (db *DB) readEntry(i int) (dbEntry, error) {
+	b := make([]byte, dbIndexEntSize)
+	if _, err := db.f.ReadAt(b, int64(dbHeaderSize+i*dbIndexEntSize)); err != nil {
+		return dbEntry{}, fmt.Errorf("%w: %w", ErrInvalidDB, err)
+	}
+	return dbEntry{
+		hash:   b[:sha256.Size],
+		offset: int64(binary.BigEndian.Uint64(b[sha256.Size:])), //nolint:gosec
+		length: binary.BigEndian.Uint32(b[sha256.Size+8:]),
+	}, nil
+}
+
+func (db *DB) readVideo(entry dbEntry) (Video, error) {
+	data := make([]byte, entry.length)
+	if _, err := db.f.ReadAt(data, entry.offset); err != nil {
+		return Video{}, fmt.Errorf("%w: %w", ErrInvalidDB, err)
+	}
+
+	var video Video
+	if err := json.Unmarshal(data, &video); err != nil {
+		return Video{}, fmt.Errorf("%w: %w", ErrInvalidDB, err)
+	}
+	return video, nil
+}
+
+func (db *DB) Close() error {
+	return db.f.Close()
+}
@@ -0,0 +1,253 @@
+package sponsorblock
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gabe565.com/castsponsorskip/internal/config"
+)
+
+var ErrInvalidParam = errors.New("invalid parameter")
+
+// upstreamPrefixLen is the hash prefix length used to fill the mirror cache.
+// Longer requested prefixes share the same upstream request.
+const upstreamPrefixLen = 4
+
+// Mirror serves a SponsorBlock-compatible skipSegments API.
+// Segments are read from the imported database in local lookup mode,
+// and misses are filled from the configured server and cached.
+type Mirror struct {
+	conf *config.Config
+	ttl  time.Duration
+
+	mu    sync.Mutex
+	cache map[string]mirrorEntry
+	now   func() time.Time
+}
+
+type mirrorEntry struct {
+	videos  []Video
+	expires time.Time
+}
+
+// NewMirror creates a mirror that caches upstream responses for ttl.
+func NewMirror(conf *config.Config, ttl time.Duration) *Mirror {
+	return &Mirror{
+		conf:  conf,
+		ttl:   ttl,
+		cache: make(map[string]mirrorEntry),
+		now:   time.Now,
+	}
+}
+
+func (m *Mirror) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/skipSegments/{prefix}", m.handlePrefix)
+	mux.HandleFunc("GET /api/skipSegments", m.handleVideo)
+	return mux
+}
+
+func (m *Mirror) handlePrefix(w http.ResponseWriter, r *http.Request) {
+	prefix := strings.ToLower(r.PathValue("prefix"))
+	if len(prefix) < config.MinHashPrefix || len(prefix) > config.MaxHashPrefix || !isHex(prefix) {
+		http.Error(w, "Hash prefix does not match format requirements.", http.StatusBadRequest)
+		return
+	}
+
+	categories, actionTypes, err := parseFilters(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	videos, err := m.videos(r.Context(), prefix)
+	if err != nil {
+		m.upstreamError(w, err)
+		return
+	}
+
+	result := make([]Video, 0, len(videos))
+	for _, video := range videos {
+		if segments := filterSegments(categories, actionTypes, video.Segments); len(segments) != 0 {
+			result = append(result, Video{VideoID: video.VideoID, Hash: video.Hash, Segments: segments})
+		}
+	}
+	writeSegments(w, result, len(result))
+}
+
+func (m *Mirror) handleVideo(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	id := query.Get("videoID")
+	if id == "" {
+		http.Error(w, "videoID not specified", http.StatusBadRequest)
+		return
+	}
+
+	categories, actionTypes, err := parseFilters(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	videos, err := m.videos(r.Context(), HashPrefix(id, hashLen))
+	if err != nil {
+		m.upstreamError(w, err)
+		return
+	}
+
+	var segments []Segment
+	for _, video := range videos {
+		if video.VideoID == id {
+			segments = filterSegments(categories, actionTypes, video.Segments)
+			break
+		}
+	}
+	writeSegments(w, segments, len(segments))
+}
+
+// videos returns every video with segments whose ID hash starts with prefix.
+// In local lookup mode with fallback enabled, upstream videos missing from the local database are included.
+func (m *Mirror) videos(ctx context.Context, prefix string) ([]Video, error) {
+	if m.conf.SponsorBlockLookup != config.LookupLocal {
+		return m.upstreamVideos(ctx, prefix)
+	}
+
+	var local []Video
+	err := withLocalDB(m.conf, func(db *DB) error {
+		var err error
+		local, err = db.LookupPrefix(prefix)
+		return err
+	})
+	switch {
+	case !m.conf.SponsorBlockDBFallback:
+		return local, err
+	case err != nil:
+		config.Logger(config.SubsystemSponsorBlock).Warn("Failed to read local SponsorBlock database. Querying server.", "error", err.Error())
+	}
+
+	remote, err := m.upstreamVideos(ctx, prefix)
+	if err != nil {
+		if len(local) == 0 {
+			return nil, err
+		}
+		config.Logger(config.SubsystemSponsorBlock).Warn("Failed to query SponsorBlock server. Using local database.", "error", err.Error())
+		return local, nil
+	}
+	return mergeVideos(local, remote), nil
+}
+
+// upstreamVideos returns every upstream video with segments whose ID hash starts with prefix.
+func (m *Mirror) upstreamVideos(ctx context.Context, prefix string) ([]Video, error) {
+	key := prefix[:min(len(prefix), upstreamPrefixLen)]
+	videos, err := m.upstream(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]Video, 0, len(videos))
+	for _, video := range videos {
+		if video.Hash == "" {
+			video.Hash = HashPrefix(video.VideoID, hashLen)
+		}
+		if strings.HasPrefix(video.Hash, prefix) {
+			matched = append(matched, video)
+		}
+	}
+	return matched, nil
+}
+
+// mergeVideos appends the remote videos that are missing from local.
+// Videos found in both keep their local segments.
+func mergeVideos(local, remote []Video) []Video {
+	merged := slices.Clip(local)
+	for _, video := range remote {
+		if !slices.ContainsFunc(local, func(v Video) bool { return v.VideoID == video.VideoID }) {
+			merged = append(merged, video)
+		}
+	}
+	return merged
+}
+
+// upstream returns the cached upstream response for a prefix, requesting it if missing or expired.
+// Every category and action type is requested so the cache can answer any filter.
+func (m *Mirror) upstream(ctx context.Context, prefix string) ([]Video, error) {
+	m.mu.Lock()
+	entry, ok := m.cache[prefix]
+	m.mu.Unlock()
+	if ok && m.now().Before(entry.expires) {
+		return entry.videos, nil
+	}
+
+	videos, err := QueryPrefix(ctx, serverURL(m.conf), prefix, config.KnownCategories, config.KnownActionTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	for key, entry := range m.cache {
+		if !now.Before(entry.expires) {
+			delete(m.cache, key)
+		}
+	}
+	m.cache[prefix] = mirrorEntry{videos: videos, expires: now.Add(m.ttl)}
+	return videos, nil
+}
+
+func (m *Mirror) upstreamError(w http.ResponseWriter, err error) {
+	config.Logger(config.SubsystemSponsorBlock).Error("Failed to query segments.", "error", err.Error())
+	http.Error(w, "Failed to query segments", http.StatusBadGateway)
+}
+
+// parseFilters reads the categories and action types of a request.
+// Like the SponsorBlock API, each accepts repeated params or a JSON array.
+func parseFilters(query url.Values) ([]string, []string, error) {
+	categories, err := parseList(query, "category", "categories", []string{"sponsor"})
+	if err != nil {
+		return nil, nil, err
+	}
+	actionTypes, err := parseList(query, "actionType", "actionTypes", []string{ActionTypeSkip})
+	if err != nil {
+		return nil, nil, err
+	}
+	return categories, actionTypes, nil
+}
+
+func parseList(query url.Values, single, plural string, defaults []string) ([]string, error) {
+	if raw := query.Get(plural); raw != "" {
+		var values []string
+		if err := json.Unmarshal([]byte(raw), &values); err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidParam, plural, err)
+		}
+		return values, nil
+	}
+	if values := query[single]; len(values) != 0 {
+		return values, nil
+	}
+	return defaults, nil
+}
+
+// writeSegments writes v as JSON, or a 404 like the SponsorBlock API if it is empty.
+func writeSegments(w http.ResponseWriter, v any, n int) {
+	if n == 0 {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func isHex(s string) bool {
+	_, err := hex.DecodeString(s + strings.Repeat("0", len(s)%2))
+	return err == nil
+}
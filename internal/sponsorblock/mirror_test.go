@@ -0,0 +1,206 @@
+package sponsorblock
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMirror(t *testing.T) (*Mirror, *httptest.Server, *int) {
+	var requests int
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Regexp(t, `^/api/skipSegments/[0-9a-f]{4}$`, r.URL.Path)
+		assert.Equal(t, config.KnownCategories, r.URL.Query()["category"])
+		_ = json.NewEncoder(w).Encode([]Video{
+			{
+				VideoID: "dQw4w9WgXcQ",
+				Hash:    HashPrefix("dQw4w9WgXcQ", hashLen),
+				Segments: []Segment{
+					{Segment: [2]float32{1, 2}, UUID: "sponsor", Category: "sponsor", ActionType: ActionTypeSkip},
+					{Segment: [2]float32{3, 4}, UUID: "selfpromo", Category: "selfpromo", ActionType: ActionTypeSkip},
+					{Segment: [2]float32{5, 6}, UUID: "mute", Category: "sponsor", ActionType: ActionTypeMute},
+				},
+			},
+			{
+				VideoID:  "other",
+				Hash:     "5f6bffff",
+				Segments: []Segment{{Segment: [2]float32{1, 2}, UUID: "other", Category: "sponsor", ActionType: ActionTypeSkip}},
+			},
+		})
+	}))
+	t.Cleanup(upstream.Close)
+
+	conf := config.New()
+	var err error
+	conf.SponsorBlockServer, err = url.Parse(upstream.URL)
+	require.NoError(t, err)
+
+	m := NewMirror(conf, time.Minute)
+	server := httptest.NewServer(m.Handler())
+	t.Cleanup(server.Close)
+	return m, server, &requests
+}
+
+func getMirror(t *testing.T, server *httptest.Server, path string) (int, []byte) {
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL+path, nil)
+	require.NoError(t, err)
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = resp.Body.Close()
+	})
+
+	var body json.RawMessage
+	if resp.StatusCode == http.StatusOK {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	}
+	return resp.StatusCode, body
+}
+
+func TestMirror(t *testing.T) {
+	m, server, requests := newTestMirror(t)
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantUUIDs  map[string][]string
+	}{
+		{
+			"prefix",
+			"/api/skipSegments/5f6b",
+			http.StatusOK,
+			map[string][]string{"dQw4w9WgXcQ": {"sponsor"}, "other": {"other"}},
+		},
+		{
+			"longer prefix",
+			"/api/skipSegments/5F6B0B?category=sponsor&actionType=skip&actionType=mute",
+			http.StatusOK,
+			map[string][]string{"dQw4w9WgXcQ": {"sponsor", "mute"}},
+		},
+		{
+			"video ID",
+			`/api/skipSegments?videoID=dQw4w9WgXcQ&categories=["sponsor","selfpromo"]`,
+			http.StatusOK,
+			map[string][]string{"dQw4w9WgXcQ": {"sponsor", "selfpromo"}},
+		},
+		{"no segments for filter", "/api/skipSegments/5f6b?category=intro", http.StatusNotFound, nil},
+		{"unknown video ID", "/api/skipSegments?videoID=aaaaaaaaaaa", http.StatusNotFound, nil},
+		{"invalid prefix", "/api/skipSegments/zzzz", http.StatusBadRequest, nil},
+		{"prefix too short", "/api/skipSegments/5f", http.StatusBadRequest, nil},
+		{"prefix too long", "/api/skipSegments/" + HashPrefix("dQw4w9WgXcQ", 33), http.StatusBadRequest, nil},
+		{"missing video ID", "/api/skipSegments", http.StatusBadRequest, nil},
+		{"invalid categories", "/api/skipSegments/5f6b?categories=sponsor", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := getMirror(t, server, tt.path)
+			require.Equal(t, tt.wantStatus, status)
+			if tt.wantUUIDs == nil {
+				return
+			}
+
+			got := make(map[string][]string)
+			if tt.name == "video ID" {
+				var segments []Segment
+				require.NoError(t, json.Unmarshal(body, &segments))
+				for _, segment := range segments {
+					got["dQw4w9WgXcQ"] = append(got["dQw4w9WgXcQ"], segment.UUID)
+				}
+			} else {
+				var videos []Video
+				require.NoError(t, json.Unmarshal(body, &videos))
+				for _, video := range videos {
+					assert.NotEmpty(t, video.Hash)
+					for _, segment := range video.Segments {
+						got[video.VideoID] = append(got[video.VideoID], segment.UUID)
+					}
+				}
+			}
+			assert.Equal(t, tt.wantUUIDs, got)
+		})
+	}
+	assert.Equal(t, 2, *requests, "upstream responses should be cached per 4 character prefix")
+
+	m.now = func() time.Time { return time.Now().Add(time.Hour) }
+	status, _ := getMirror(t, server, "/api/skipSegments/5f6b")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 3, *requests, "expired responses should be requested again")
+}
+
+func TestMirror_QuerySegments(t *testing.T) {
+	_, server, _ := newTestMirror(t)
+
+	for _, lookup := range []string{config.LookupHash, config.LookupDirect} {
+		t.Run(lookup, func(t *testing.T) {
+			conf := config.New()
+			conf.SponsorBlockLookup = lookup
+			var err error
+			conf.SponsorBlockServer, err = url.Parse(server.URL)
+			require.NoError(t, err)
+
+			got, err := QuerySegments(t.Context(), conf, "dQw4w9WgXcQ")
+			require.NoError(t, err)
+			require.Len(t, got, 2)
+			assert.Equal(t, "sponsor", got[0].UUID)
+			assert.Equal(t, "mute", got[1].UUID)
+		})
+	}
+}
+
+func TestMirror_Local(t *testing.T) {
+	m, server, requests := newTestMirror(t)
+	m.conf.SponsorBlockLookup = config.LookupLocal
+	m.conf.SponsorBlockDBFile = importTestDump(t)
+
+	status, body := getMirror(t, server, "/api/skipSegments?videoID=dQw4w9WgXcQ")
+	require.Equal(t, http.StatusOK, status)
+	var segments []Segment
+	require.NoError(t, json.Unmarshal(body, &segments))
+	require.Len(t, segments, 2)
+	assert.Equal(t, "uuid-b", segments[0].UUID)
+
+	status, _ = getMirror(t, server, "/api/skipSegments/"+HashPrefix("y8Kyi0WNg40", 5))
+	assert.Equal(t, http.StatusOK, status)
+
+	status, _ = getMirror(t, server, "/api/skipSegments?videoID=aaaaaaaaaaa")
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Zero(t, *requests, "upstream should not be queried without fallback")
+
+	m.conf.SponsorBlockDBFallback = true
+	status, _ = getMirror(t, server, "/api/skipSegments/5f6bff")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 1, *requests)
+}
+
+func TestMirror_LocalMerge(t *testing.T) {
+	m, server, requests := newTestMirror(t)
+	m.conf.SponsorBlockLookup = config.LookupLocal
+	m.conf.SponsorBlockDBFile = importTestDump(t)
+	m.conf.SponsorBlockDBFallback = true
+
+	status, body := getMirror(t, server, "/api/skipSegments/5f6b")
+	require.Equal(t, http.StatusOK, status)
+	var videos []Video
+	require.NoError(t, json.Unmarshal(body, &videos))
+
+	got := make(map[string][]string, len(videos))
+	for _, video := range videos {
+		for _, segment := range video.Segments {
+			got[video.VideoID] = append(got[video.VideoID], segment.UUID)
+		}
+	}
+	assert.Equal(t, map[string][]string{
+		"dQw4w9WgXcQ": {"uuid-b", "uuid-c"},
+		"other":       {"other"},
+	}, got, "local videos should be kept and upstream videos under the same prefix added")
+	assert.Equal(t, 1, *requests)
+}
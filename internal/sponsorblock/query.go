@@ -8,16 +8,23 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log/slog"
 	"net/http"
 	"net/url"
+	"os"
 	"path"
+	"slices"
+	"sync"
 
 	"gabe565.com/castsponsorskip/internal/config"
+	"gabe565.com/castsponsorskip/internal/tracing"
+	"gabe565.com/utils/must"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Video struct {
 	VideoID  string    `json:"videoID"`
+	Hash     string    `json:"hash,omitempty"`
 	Segments []Segment `json:"segments"`
 }
 
@@ -32,39 +39,126 @@ type Segment struct {
 	Description   string     `json:"description"`
 }
 
-var ErrStatusCode = errors.New("invalid response status")
+var (
+	ErrStatusCode        = errors.New("invalid response status")
+	ErrInvalidHashPrefix = errors.New("invalid hash prefix")
+)
 
 //nolint:gochecknoglobals
-var baseURL = url.URL{
-	Scheme: "https",
-	Host:   "sponsor.ajay.app",
+var (
+	baseURL = *must.Must2(url.Parse(config.DefaultSponsorBlockURL))
+
+	client = &http.Client{Transport: tracing.Transport(nil)}
+)
+
+// serverURL returns the configured server, or the public server if the config was not loaded.
+func serverURL(conf *config.Config) url.URL {
+	if conf.SponsorBlockServer != nil {
+		return *conf.SponsorBlockServer
+	}
+	return baseURL
+}
+
+func QuerySegments(ctx context.Context, conf *config.Config, id string) (segments []Segment, err error) {
+	ctx, span := tracing.Start(ctx, "sponsorblock.QuerySegments",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("video_id", id),
+			attribute.String("lookup", conf.SponsorBlockLookup),
+		),
+	)
+	defer func() {
+		span.SetAttributes(attribute.Int("segments", len(segments)))
+		tracing.End(span, err)
+	}()
+
+	if conf.SponsorBlockLookup == config.LookupLocal {
+		segments, found, err := queryLocal(conf, id)
+		switch {
+		case err == nil && found:
+			span.SetAttributes(attribute.Bool("local", true))
+			return filterSegments(conf.Categories, conf.ActionTypes, segments), nil
+		case !conf.SponsorBlockDBFallback:
+			return nil, err
+		case err != nil:
+			config.Logger(config.SubsystemSponsorBlock).Warn("Failed to read local SponsorBlock database. Querying server.", "error", err.Error())
+		default:
+			config.Logger(config.SubsystemSponsorBlock).Debug("Video not in local SponsorBlock database. Querying server.", "video_id", id)
+		}
+	}
+
+	if conf.SponsorBlockLookup == config.LookupDirect {
+		u := serverURL(conf)
+		u.Path = path.Join("/", u.Path, "api", "skipSegments")
+		query := filterQuery(conf.Categories, conf.ActionTypes)
+		query.Set("videoID", id)
+		u.RawQuery = query.Encode()
+
+		// Direct lookups return the segments of a single video
+		if err := getSegments(ctx, u, &segments); err != nil {
+			return nil, err
+		}
+		return filterSegments(conf.Categories, conf.ActionTypes, segments), nil
+	}
+
+	videos, err := QueryPrefix(ctx, serverURL(conf), HashPrefix(id, hashPrefixLen(conf)), conf.Categories, conf.ActionTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, video := range videos {
+		if video.VideoID == id {
+			return filterSegments(conf.Categories, conf.ActionTypes, video.Segments), nil
+		}
+	}
+
+	return nil, nil
+}
+
+// hashLen is the length of a hex encoded SHA-256 hash.
+const hashLen = 2 * sha256.Size
+
+// HashPrefix returns the first n characters of the hex encoded SHA-256 hash of a video ID.
+func HashPrefix(id string, n int) string {
+	checksum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(checksum[:])[:n]
 }
 
-func QuerySegments(ctx context.Context, conf *config.Config, id string) ([]Segment, error) {
-	checksumBytes := sha256.Sum256([]byte(id))
-	checksum := hex.EncodeToString(checksumBytes[:])
+// QueryPrefix returns every video with segments whose ID hash starts with prefix.
+func QueryPrefix(ctx context.Context, server url.URL, prefix string, categories, actionTypes []string) ([]Video, error) {
+	server.Path = path.Join("/", server.Path, "api", "skipSegments", prefix)
+	server.RawQuery = filterQuery(categories, actionTypes).Encode()
+
+	var videos []Video
+	if err := getSegments(ctx, server, &videos); err != nil {
+		return nil, err
+	}
+	return videos, nil
+}
 
-	query := make(url.Values, len(conf.Categories)+len(conf.ActionTypes))
-	for _, category := range conf.Categories {
+func filterQuery(categories, actionTypes []string) url.Values {
+	query := make(url.Values, 3)
+	for _, category := range categories {
 		query.Add("category", category)
 	}
-	for _, actionType := range conf.ActionTypes {
+	for _, actionType := range actionTypes {
 		query.Add("actionType", actionType)
 	}
+	return query
+}
 
-	u := baseURL
-	u.Path = path.Join("api", "skipSegments", checksum[:4])
-	u.RawQuery = query.Encode()
-
-	slog.Debug("Request segments", "url", u.String())
+// getSegments decodes a skipSegments response into v.
+// A 404 means no segments were found, so v is left unchanged.
+func getSegments(ctx context.Context, u url.URL, v any) error {
+	config.Logger(config.SubsystemSponsorBlock).Debug("Request segments", "url", u.String())
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer func() {
 		_, _ = io.Copy(io.Discard, resp.Body)
@@ -73,22 +167,78 @@ func QuerySegments(ctx context.Context, conf *config.Config, id string) ([]Segme
 
 	if resp.StatusCode != http.StatusOK {
 		if resp.StatusCode == http.StatusNotFound {
-			return nil, nil
+			return nil
 		}
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("%w: %s %s", ErrStatusCode, resp.Status, body)
+		return fmt.Errorf("%w: %s %s", ErrStatusCode, resp.Status, body)
 	}
 
-	var videos []Video
-	if err := json.NewDecoder(resp.Body).Decode(&videos); err != nil {
-		return nil, err
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func hashPrefixLen(conf *config.Config) int {
+	return min(max(conf.SponsorBlockHashPrefix, config.MinHashPrefix), config.MaxHashPrefix)
+}
+
+// filterSegments removes segments with a category or action type that was not requested.
+// Servers should already filter these, but self-hosted servers and mirrors may not.
+func filterSegments(categories, actionTypes []string, segments []Segment) []Segment {
+	filtered := make([]Segment, 0, len(segments))
+	for _, segment := range segments {
+		if slices.Contains(categories, segment.Category) &&
+			(segment.ActionType == "" || slices.Contains(actionTypes, segment.ActionType)) {
+			filtered = append(filtered, segment)
+		}
+	}
+	if len(filtered) == 0 {
+		return nil
+	}
+	return filtered
+}
+
+//nolint:gochecknoglobals
+var localDB struct {
+	mu sync.Mutex
+	db *DB
+}
+
+// queryLocal looks up a video in the imported database.
+func queryLocal(conf *config.Config, id string) (segments []Segment, found bool, err error) {
+	err = withLocalDB(conf, func(db *DB) error {
+		segments, found, err = db.Lookup(id)
+		return err
+	})
+	return segments, found, err
+}
+
+// withLocalDB calls fn with the imported database.
+// The database is reopened when it is imported again.
+func withLocalDB(conf *config.Config, fn func(db *DB) error) error {
+	path, err := conf.SponsorBlockDBPath()
+	if err != nil {
+		return err
 	}
 
-	for _, video := range videos {
-		if video.VideoID == id {
-			return video.Segments, nil
+	stat, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("%w; run the import-db command to create it", err)
+	}
+
+	localDB.mu.Lock()
+	defer localDB.mu.Unlock()
+
+	if db := localDB.db; db == nil || db.f.Name() != path || !db.modTime.Equal(stat.ModTime()) {
+		if db != nil {
+			_ = db.Close()
+			localDB.db = nil
 		}
+		if localDB.db, err = OpenDB(path); err != nil {
+			return err
+		}
+		config.Logger(config.SubsystemSponsorBlock).Info("Opened local SponsorBlock database.",
+			"path", path, "videos", localDB.db.Len(),
+		)
 	}
 
-	return nil, nil
+	return fn(localDB.db)
 }
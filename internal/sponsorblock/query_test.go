@@ -18,21 +18,75 @@ func TestQuerySegmentsRequest(t *testing.T) {
 		actionTypes []string
 	}
 	tests := []struct {
-		name     string
-		args     args
-		wantPath string
-		wantErr  require.ErrorAssertionFunc
+		name       string
+		args       args
+		lookup     string
+		hashPrefix int
+		wantPath   string
+		wantID     string
+		wantErr    require.ErrorAssertionFunc
 	}{
 		{
 			"1",
 			args{"dQw4w9WgXcQ", []string{"sponsor"}, []string{"skip", "mute"}},
+			config.LookupHash,
+			4,
 			"/api/skipSegments/5f6b",
+			"",
 			require.NoError,
 		},
 		{
 			"2",
 			args{"y8Kyi0WNg40", []string{"sponsor", "selfpromo"}, []string{"skip", "mute"}},
+			config.LookupHash,
+			4,
 			"/api/skipSegments/30cc",
+			"",
+			require.NoError,
+		},
+		{
+			"longer prefix",
+			args{"dQw4w9WgXcQ", []string{"sponsor"}, []string{"skip"}},
+			config.LookupHash,
+			8,
+			"/api/skipSegments/5f6b0b4e",
+			"",
+			require.NoError,
+		},
+		{
+			"shorter prefix",
+			args{"dQw4w9WgXcQ", []string{"sponsor"}, []string{"skip"}},
+			config.LookupHash,
+			3,
+			"/api/skipSegments/5f6",
+			"",
+			require.NoError,
+		},
+		{
+			"prefix below server minimum",
+			args{"dQw4w9WgXcQ", []string{"sponsor"}, []string{"skip"}},
+			config.LookupHash,
+			1,
+			"/api/skipSegments/5f6",
+			"",
+			require.NoError,
+		},
+		{
+			"prefix above server maximum",
+			args{"dQw4w9WgXcQ", []string{"sponsor"}, []string{"skip"}},
+			config.LookupHash,
+			64,
+			"/api/skipSegments/" + HashPrefix("dQw4w9WgXcQ", 32),
+			"",
+			require.NoError,
+		},
+		{
+			"direct",
+			args{"dQw4w9WgXcQ", []string{"sponsor"}, []string{"skip", "mute"}},
+			config.LookupDirect,
+			4,
+			"/api/skipSegments",
+			"dQw4w9WgXcQ",
 			require.NoError,
 		},
 	}
@@ -41,9 +95,12 @@ func TestQuerySegmentsRequest(t *testing.T) {
 			conf := config.New()
 			conf.Categories = tt.args.categories
 			conf.ActionTypes = tt.args.actionTypes
+			conf.SponsorBlockLookup = tt.lookup
+			conf.SponsorBlockHashPrefix = tt.hashPrefix
 
 			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				assert.Equal(t, tt.wantPath, r.URL.Path)
+				assert.Equal(t, tt.wantID, r.URL.Query().Get("videoID"))
 				assert.Equal(t, tt.args.categories, r.URL.Query()["category"])
 				assert.Equal(t, tt.args.actionTypes, r.URL.Query()["actionType"])
 				_, _ = w.Write([]byte("[]"))
@@ -168,3 +225,35 @@ func TestQuerySegmentsResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestQuerySegmentsFilter(t *testing.T) {
+	segments := `[
+		{"category":"sponsor","actionType":"skip","segment":[1,2],"UUID":"a"},
+		{"category":"selfpromo","actionType":"skip","segment":[3,4],"UUID":"b"},
+		{"category":"sponsor","actionType":"full","segment":[0,0],"UUID":"c"}
+	]`
+
+	for _, lookup := range []string{config.LookupHash, config.LookupDirect} {
+		t.Run(lookup, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if lookup == config.LookupDirect {
+					_, _ = w.Write([]byte(segments))
+				} else {
+					_, _ = w.Write([]byte(`[{"videoID":"dQw4w9WgXcQ","segments":` + segments + `}]`))
+				}
+			}))
+			t.Cleanup(server.Close)
+
+			conf := config.New()
+			conf.SponsorBlockLookup = lookup
+			var err error
+			conf.SponsorBlockServer, err = url.Parse(server.URL)
+			require.NoError(t, err)
+
+			got, err := QuerySegments(t.Context(), conf, "dQw4w9WgXcQ")
+			require.NoError(t, err)
+			require.Len(t, got, 1)
+			assert.Equal(t, "a", got[0].UUID)
+		})
+	}
+}
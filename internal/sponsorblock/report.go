@@ -0,0 +1,136 @@
+package sponsorblock
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"gabe565.com/castsponsorskip/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// ReportInterval is how often queued skip reports are sent.
+	ReportInterval = 30 * time.Second
+
+	reportQueueSize = 256
+)
+
+// Reporter reports skipped segments to SponsorBlock in the background,
+// which feeds segment view counts like the browser extension does.
+type Reporter struct {
+	conf     *config.Config
+	interval time.Duration
+	queue    chan string
+	done     chan struct{}
+}
+
+func NewReporter(conf *config.Config, interval time.Duration) *Reporter {
+	return &Reporter{
+		conf:     conf,
+		interval: interval,
+		queue:    make(chan string, reportQueueSize),
+		done:     make(chan struct{}),
+	}
+}
+
+// Report queues a skipped segment. It never blocks; reports are dropped if the queue is full.
+func (r *Reporter) Report(uuid string) {
+	select {
+	case r.queue <- uuid:
+	default:
+		config.Logger(config.SubsystemSponsorBlock).Debug("Skip report queue is full. Dropping report.", "uuid", uuid)
+	}
+}
+
+// Run sends queued reports every interval until ctx is canceled, then sends the remaining reports.
+func (r *Reporter) Run(ctx context.Context) {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	batch := make(map[string]struct{})
+	for {
+		select {
+		case uuid := <-r.queue:
+			batch[uuid] = struct{}{}
+		case <-ticker.C:
+			r.flush(ctx, batch)
+		case <-ctx.Done():
+			r.drain(batch)
+			flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+			r.flush(flushCtx, batch)
+			cancel()
+			return
+		}
+	}
+}
+
+// Wait blocks until Run has sent the remaining reports.
+func (r *Reporter) Wait() {
+	<-r.done
+}
+
+// drain moves every queued report into batch.
+func (r *Reporter) drain(batch map[string]struct{}) {
+	for {
+		select {
+		case uuid := <-r.queue:
+			batch[uuid] = struct{}{}
+		default:
+			return
+		}
+	}
+}
+
+func (r *Reporter) flush(ctx context.Context, batch map[string]struct{}) {
+	for uuid := range batch {
+		if err := ReportView(ctx, r.conf, uuid); err != nil {
+			config.Logger(config.SubsystemSponsorBlock).Warn("Failed to report skip.", "uuid", uuid, "error", err.Error())
+		}
+		delete(batch, uuid)
+	}
+}
+
+// ReportView reports that a segment was skipped.
+func ReportView(ctx context.Context, conf *config.Config, uuid string) (err error) {
+	ctx, span := tracing.Start(ctx, "sponsorblock.ReportView",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("uuid", uuid)),
+	)
+	defer func() {
+		tracing.End(span, err)
+	}()
+
+	u := serverURL(conf)
+	u.Path = path.Join("/", u.Path, "api", "viewedVideoSponsorTime")
+	u.RawQuery = url.Values{"UUID": []string{uuid}}.Encode()
+
+	config.Logger(config.SubsystemSponsorBlock).Debug("Report skip", "uuid", uuid)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%w: %s %s", ErrStatusCode, resp.Status, body)
+	}
+	return nil
+}
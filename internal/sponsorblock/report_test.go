@@ -0,0 +1,85 @@
+package sponsorblock
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newReportServer(t *testing.T) (*config.Config, func() []string) {
+	var mu sync.Mutex
+	var reported []string
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/viewedVideoSponsorTime", r.URL.Path)
+		mu.Lock()
+		defer mu.Unlock()
+		reported = append(reported, r.URL.Query().Get("UUID"))
+	}))
+	t.Cleanup(server.Close)
+
+	conf := config.New()
+	var err error
+	conf.SponsorBlockServer, err = url.Parse(server.URL)
+	require.NoError(t, err)
+
+	return conf, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		result := slices.Clone(reported)
+		slices.Sort(result)
+		return result
+	}
+}
+
+func TestReporter(t *testing.T) {
+	conf, reported := newReportServer(t)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	t.Cleanup(cancel)
+	r := NewReporter(conf, 10*time.Millisecond)
+	go r.Run(ctx)
+
+	r.Report("a")
+	r.Report("a")
+	r.Report("b")
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, []string{"a", "b"}, reported())
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	r.Wait()
+}
+
+func TestReporter_FlushOnCancel(t *testing.T) {
+	conf, reported := newReportServer(t)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	r := NewReporter(conf, time.Hour)
+	r.Report("a")
+	r.Report("b")
+	r.Report("a")
+
+	go r.Run(ctx)
+	cancel()
+	r.Wait()
+	assert.Equal(t, []string{"a", "b"}, reported())
+}
+
+func TestReporter_Report(t *testing.T) {
+	r := NewReporter(config.New(), time.Hour)
+	for range reportQueueSize + 1 {
+		r.Report("a")
+	}
+	// Reports are dropped instead of blocking once the queue is full
+	assert.Len(t, r.queue, reportQueueSize)
+}
@@ -0,0 +1,132 @@
+package sponsorblock
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"gabe565.com/castsponsorskip/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	ErrNoUserID       = errors.New("no SponsorBlock user ID configured")
+	ErrInvalidSegment = errors.New("invalid segment")
+)
+
+const userAgent = "CastSponsorSkip"
+
+type Submission struct {
+	VideoID       string
+	Start         float32
+	End           float32
+	Category      string
+	ActionType    string
+	VideoDuration float32
+}
+
+type submitRequest struct {
+	VideoID       string          `json:"videoID"`
+	UserID        string          `json:"userID"`
+	UserAgent     string          `json:"userAgent"`
+	VideoDuration float32         `json:"videoDuration,omitempty"`
+	Segments      []submitSegment `json:"segments"`
+}
+
+type submitSegment struct {
+	Segment    [2]float32 `json:"segment"`
+	Category   string     `json:"category"`
+	ActionType string     `json:"actionType"`
+}
+
+// SubmitSegment submits a new segment with the configured private user ID.
+// The returned segment has the UUID assigned by the server.
+func SubmitSegment(ctx context.Context, conf *config.Config, s Submission) (segment Segment, err error) {
+	ctx, span := tracing.Start(ctx, "sponsorblock.SubmitSegment",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("video_id", s.VideoID),
+			attribute.String("category", s.Category),
+			attribute.Float64("from", float64(s.Start)),
+			attribute.Float64("to", float64(s.End)),
+		),
+	)
+	defer func() {
+		tracing.End(span, err)
+	}()
+
+	if conf.SponsorBlockUserID == "" {
+		return Segment{}, ErrNoUserID
+	}
+	if s.ActionType == "" {
+		s.ActionType = ActionTypeSkip
+	}
+	switch {
+	case s.VideoID == "":
+		return Segment{}, fmt.Errorf("%w: missing video ID", ErrInvalidSegment)
+	case s.Start < 0 || s.End <= s.Start:
+		return Segment{}, fmt.Errorf("%w: end %.3f must be after start %.3f", ErrInvalidSegment, s.End, s.Start)
+	}
+
+	body, err := json.Marshal(submitRequest{
+		VideoID:       s.VideoID,
+		UserID:        conf.SponsorBlockUserID,
+		UserAgent:     userAgent,
+		VideoDuration: s.VideoDuration,
+		Segments: []submitSegment{{
+			Segment:    [2]float32{s.Start, s.End},
+			Category:   s.Category,
+			ActionType: s.ActionType,
+		}},
+	})
+	if err != nil {
+		return Segment{}, err
+	}
+
+	u := serverURL(conf)
+	u.Path = path.Join("/", u.Path, "api", "skipSegments")
+	config.Logger(config.SubsystemSponsorBlock).Debug("Submit segment", "url", u.String(), "video_id", s.VideoID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return Segment{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return Segment{}, err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return Segment{}, fmt.Errorf("%w: %s %s", ErrStatusCode, resp.Status, body)
+	}
+
+	var submitted []Segment
+	if err := json.NewDecoder(resp.Body).Decode(&submitted); err != nil {
+		return Segment{}, err
+	}
+
+	segment = Segment{
+		Segment:       [2]float32{s.Start, s.End},
+		Category:      s.Category,
+		ActionType:    s.ActionType,
+		VideoDuration: s.VideoDuration,
+	}
+	if len(submitted) != 0 {
+		segment.UUID = submitted[0].UUID
+	}
+	span.SetAttributes(attribute.String("uuid", segment.UUID))
+	return segment, nil
+}
@@ -0,0 +1,81 @@
+package sponsorblock
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testUserID = "0123456789abcdef0123456789abcdef"
+
+func TestSubmitSegment(t *testing.T) {
+	var got submitRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/skipSegments", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		if !assert.NoError(t, json.NewDecoder(r.Body).Decode(&got)) {
+			return
+		}
+		if strings.HasPrefix(got.VideoID, "dup") {
+			http.Error(w, "Sponsors has already been submitted before.", http.StatusConflict)
+			return
+		}
+		_ = json.NewEncoder(w).Encode([]Segment{{UUID: "new-uuid", Category: "sponsor", Segment: [2]float32{10, 20.5}}})
+	}))
+	t.Cleanup(server.Close)
+
+	conf := config.New()
+	var err error
+	conf.SponsorBlockServer, err = url.Parse(server.URL)
+	require.NoError(t, err)
+
+	submission := Submission{VideoID: "dQw4w9WgXcQ", Start: 10, End: 20.5, Category: "sponsor", VideoDuration: 213}
+
+	t.Run("no user ID", func(t *testing.T) {
+		_, err := SubmitSegment(t.Context(), conf, submission)
+		require.ErrorIs(t, err, ErrNoUserID)
+	})
+
+	conf.SponsorBlockUserID = testUserID
+
+	t.Run("submitted", func(t *testing.T) {
+		segment, err := SubmitSegment(t.Context(), conf, submission)
+		require.NoError(t, err)
+		assert.Equal(t, "new-uuid", segment.UUID)
+		assert.Equal(t, [2]float32{10, 20.5}, segment.Segment)
+		assert.Equal(t, ActionTypeSkip, segment.ActionType)
+
+		assert.Equal(t, submitRequest{
+			VideoID:       "dQw4w9WgXcQ",
+			UserID:        testUserID,
+			UserAgent:     userAgent,
+			VideoDuration: 213,
+			Segments: []submitSegment{
+				{Segment: [2]float32{10, 20.5}, Category: "sponsor", ActionType: ActionTypeSkip},
+			},
+		}, got)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		s := submission
+		s.VideoID = "dup"
+		_, err := SubmitSegment(t.Context(), conf, s)
+		require.ErrorIs(t, err, ErrStatusCode)
+		assert.Contains(t, err.Error(), "already been submitted")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		s := submission
+		s.End = s.Start
+		_, err := SubmitSegment(t.Context(), conf, s)
+		require.ErrorIs(t, err, ErrInvalidSegment)
+	})
+}
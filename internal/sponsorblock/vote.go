@@ -0,0 +1,76 @@
+package sponsorblock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"gabe565.com/castsponsorskip/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var ErrUnknownVote = errors.New("unknown vote type")
+
+// Vote types accepted by the voteOnSponsorTime API.
+const (
+	VoteDown = 0
+	VoteUp   = 1
+	VoteUndo = 20
+)
+
+// Vote votes on a segment with the configured private user ID.
+func Vote(ctx context.Context, conf *config.Config, uuid string, voteType int) (err error) {
+	ctx, span := tracing.Start(ctx, "sponsorblock.Vote",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("uuid", uuid),
+			attribute.Int("type", voteType),
+		),
+	)
+	defer func() {
+		tracing.End(span, err)
+	}()
+
+	switch {
+	case conf.SponsorBlockUserID == "":
+		return ErrNoUserID
+	case voteType != VoteDown && voteType != VoteUp && voteType != VoteUndo:
+		return fmt.Errorf("%w: %d", ErrUnknownVote, voteType)
+	}
+
+	u := serverURL(conf)
+	u.Path = path.Join("/", u.Path, "api", "voteOnSponsorTime")
+	u.RawQuery = url.Values{
+		"UUID":   []string{uuid},
+		"userID": []string{conf.SponsorBlockUserID},
+		"type":   []string{strconv.Itoa(voteType)},
+	}.Encode()
+
+	config.Logger(config.SubsystemSponsorBlock).Debug("Vote on segment", "uuid", uuid, "type", voteType)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%w: %s %s", ErrStatusCode, resp.Status, body)
+	}
+	return nil
+}
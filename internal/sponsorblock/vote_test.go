@@ -0,0 +1,61 @@
+package sponsorblock
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVote(t *testing.T) {
+	var got url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/voteOnSponsorTime", r.URL.Path)
+		got = r.URL.Query()
+		if got.Get("UUID") == "missing" {
+			http.Error(w, "Vote rejected", http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	conf := config.New()
+	var err error
+	conf.SponsorBlockServer, err = url.Parse(server.URL)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, Vote(t.Context(), conf, "uuid", VoteDown), ErrNoUserID)
+
+	conf.SponsorBlockUserID = testUserID
+	tests := []struct {
+		name     string
+		uuid     string
+		voteType int
+		wantErr  error
+	}{
+		{"down", "uuid", VoteDown, nil},
+		{"up", "uuid", VoteUp, nil},
+		{"undo", "uuid", VoteUndo, nil},
+		{"unknown type", "uuid", 5, ErrUnknownVote},
+		{"rejected", "missing", VoteDown, ErrStatusCode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = nil
+			err := Vote(t.Context(), conf, tt.uuid, tt.voteType)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.uuid, got.Get("UUID"))
+			assert.Equal(t, testUserID, got.Get("userID"))
+			assert.Equal(t, strconv.Itoa(tt.voteType), got.Get("type"))
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package tracing
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func setupTest(t *testing.T) *tracetest.InMemoryExporter {
+	shutdown, err := Setup(t.Context(), "", "test")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = shutdown(t.Context())
+	})
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prev)
+	})
+	return exporter
+}
+
+func TestEnd(t *testing.T) {
+	exporter := setupTest(t)
+
+	_, span := Start(t.Context(), "ok")
+	End(span, nil)
+	_, span = Start(t.Context(), "failed")
+	End(span, errors.New("test"))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, codes.Unset, spans[0].Status.Code)
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+	assert.Equal(t, "test", spans[1].Status.Description)
+}
+
+func TestTransport(t *testing.T) {
+	exporter := setupTest(t)
+
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	t.Cleanup(server.Close)
+
+	ctx, span := Start(t.Context(), "parent")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	client := &http.Client{Transport: Transport(nil)}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	span.End()
+
+	assert.Contains(t, traceparent, span.SpanContext().TraceID().String())
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, span.SpanContext().SpanID(), spans[0].Parent.SpanID())
+}
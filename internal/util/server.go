@@ -0,0 +1,52 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ShutdownTimeout is how long in-flight requests may take to finish after the server is asked to stop.
+const ShutdownTimeout = 5 * time.Second
+
+// ListenAndServe serves handler on addr until ctx is canceled, then shuts down gracefully.
+func ListenAndServe(ctx context.Context, addr string, handler http.Handler) error {
+	var lc net.ListenConfig
+	ln, err := lc.Listen(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return Serve(ctx, ln, handler)
+}
+
+// Serve serves handler on ln until ctx is canceled, then shuts down gracefully.
+// Requests don't inherit ctx, so in-flight requests can still finish during shutdown.
+func Serve(ctx context.Context, ln net.Listener, handler http.Handler) error {
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Serve(ln)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package util
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServe(t *testing.T) {
+	var lc net.ListenConfig
+	ln, err := lc.Listen(t.Context(), "tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		if r.Context().Err() != nil {
+			http.Error(w, r.Context().Err().Error(), http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = io.WriteString(w, "ok")
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	served := make(chan error, 1)
+	go func() {
+		served <- Serve(ctx, ln, handler)
+	}()
+
+	type result struct {
+		status int
+		body   string
+		err    error
+	}
+	resCh := make(chan result, 1)
+	go func() {
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://"+ln.Addr().String(), nil)
+		if err != nil {
+			resCh <- result{err: err}
+			return
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			resCh <- result{err: err}
+			return
+		}
+		defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(resp.Body)
+		resCh <- result{resp.StatusCode, string(body), err}
+	}()
+
+	<-started
+	cancel()
+	// Give Shutdown time to start before the request finishes
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	res := <-resCh
+	require.NoError(t, res.err)
+	assert.Equal(t, http.StatusOK, res.status, "in-flight requests should finish during shutdown")
+	assert.Equal(t, "ok", res.body)
+
+	select {
+	case err := <-served:
+		require.NoError(t, err)
+	case <-time.After(ShutdownTimeout):
+		t.Fatal("Serve did not return")
+	}
+}
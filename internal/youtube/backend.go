@@ -0,0 +1,168 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"gabe565.com/castsponsorskip/internal/tracing"
+	"gabe565.com/castsponsorskip/internal/util"
+)
+
+var (
+	ErrNoBackends = errors.New("no video ID backends configured")
+	ErrStatusCode = errors.New("invalid response status")
+)
+
+// Backend finds the video ID that matches a device's media metadata.
+type Backend interface {
+	Name() string
+	QueryVideoID(ctx context.Context, q Query) (string, error)
+}
+
+//nolint:gochecknoglobals
+var (
+	backend Backend
+
+	client = &http.Client{Transport: tracing.Transport(nil)}
+)
+
+// NewBackends creates the configured backends in order.
+// The YouTube Data API backend is skipped if no API key is configured.
+func NewBackends(conf *config.Config) Fallback {
+	result := make(Fallback, 0, len(conf.VideoIDBackendSpecs))
+	for _, spec := range conf.VideoIDBackendSpecs {
+		switch spec.Type {
+		case config.BackendYouTube:
+			if len(conf.YouTubeAPIKeys()) != 0 {
+				result = append(result, DataAPI{})
+			}
+		case config.BackendInvidious:
+			result = append(result, NewInvidious(spec.URL))
+		case config.BackendPiped:
+			result = append(result, NewPiped(spec.URL))
+		}
+	}
+	return result
+}
+
+// Setup creates the configured backends and the video ID cache.
+func Setup(conf *config.Config) {
+	backends := NewBackends(conf)
+	if len(backends) == 0 {
+		SetBackend(nil)
+		return
+	}
+
+	if conf.VideoIDCacheSize == 0 {
+		SetBackend(backends)
+		return
+	}
+
+	path := conf.VideoIDCacheFile
+	if path == "" {
+		var err error
+		if path, err = config.UserCachePath("video-ids.json"); err != nil {
+			config.Logger(config.SubsystemYouTube).Warn("Failed to find cache directory. Video ID cache will not be saved.", "error", err.Error())
+		}
+	}
+
+	cache := NewCache(backends, path, conf.VideoIDCacheSize, conf.VideoIDCacheTTL, conf.VideoIDCacheNegativeTTL)
+	if err := cache.Load(); err != nil {
+		config.Logger(config.SubsystemYouTube).Warn("Failed to load video ID cache.", "error", err.Error())
+	}
+	SetBackend(cache)
+}
+
+// SetBackend sets the backend used by FindVideoID.
+func SetBackend(b Backend) {
+	backend = b
+}
+
+// Enabled returns true if a backend is configured.
+func Enabled() bool {
+	return backend != nil
+}
+
+// FindVideoID searches the configured backend.
+func FindVideoID(ctx context.Context, q Query) (string, error) {
+	if backend == nil {
+		return "", util.HaltRetries(ErrNoBackends)
+	}
+	return backend.QueryVideoID(ctx, q)
+}
+
+// Fallback tries each backend in order until one returns a video ID.
+type Fallback []Backend
+
+func (f Fallback) Name() string {
+	names := make([]string, 0, len(f))
+	for _, b := range f {
+		names = append(names, b.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+// QueryVideoID returns the first video ID found.
+// Retries are only halted if every backend halted them.
+func (f Fallback) QueryVideoID(ctx context.Context, q Query) (string, error) {
+	if len(f) == 0 {
+		return "", util.HaltRetries(ErrNoBackends)
+	}
+
+	errs := make([]error, 0, len(f))
+	halt := true
+	for _, b := range f {
+		id, err := b.QueryVideoID(ctx, q)
+		if err == nil {
+			return id, nil
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+
+		var haltErr util.HaltRetriesError
+		if errors.As(err, &haltErr) {
+			err = haltErr.Err
+		} else {
+			halt = false
+		}
+		config.Logger(config.SubsystemYouTube).Debug("Video ID backend failed.", "backend", b.Name(), "error", err.Error())
+		errs = append(errs, fmt.Errorf("%s: %w", b.Name(), err))
+	}
+
+	err := errors.Join(errs...)
+	if halt {
+		return "", util.HaltRetries(err)
+	}
+	return "", err
+}
+
+func getJSON(ctx context.Context, u string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%w: %s %s", ErrStatusCode, resp.Status, body)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
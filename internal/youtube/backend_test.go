@@ -0,0 +1,88 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"gabe565.com/castsponsorskip/internal/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubBackend struct {
+	name  string
+	id    string
+	err   error
+	calls int
+}
+
+func (s *stubBackend) Name() string { return s.name }
+
+func (s *stubBackend) QueryVideoID(context.Context, Query) (string, error) {
+	s.calls++
+	return s.id, s.err
+}
+
+func TestFallback_QueryVideoID(t *testing.T) {
+	errDown := errors.New("down")
+
+	t.Run("first success", func(t *testing.T) {
+		first := &stubBackend{name: "first", err: util.HaltRetries(ErrNoMatches)}
+		second := &stubBackend{name: "second", id: "dQw4w9WgXcQ"}
+		third := &stubBackend{name: "third", id: "aaaaaaaaaaa"}
+
+		got, err := Fallback{first, second, third}.QueryVideoID(t.Context(), Query{Artist: "artist", Title: "title"})
+		require.NoError(t, err)
+		assert.Equal(t, "dQw4w9WgXcQ", got)
+		assert.Equal(t, 1, first.calls)
+		assert.Equal(t, 1, second.calls)
+		assert.Zero(t, third.calls)
+	})
+
+	t.Run("all halted", func(t *testing.T) {
+		_, err := Fallback{
+			&stubBackend{name: "first", err: util.HaltRetries(ErrNoMatches)},
+			&stubBackend{name: "second", err: util.HaltRetries(ErrNoVideos)},
+		}.QueryVideoID(t.Context(), Query{Artist: "artist", Title: "title"})
+		require.ErrorIs(t, err, ErrNoMatches)
+		require.ErrorIs(t, err, ErrNoVideos)
+		var haltErr util.HaltRetriesError
+		assert.ErrorAs(t, err, &haltErr)
+	})
+
+	t.Run("retryable", func(t *testing.T) {
+		_, err := Fallback{
+			&stubBackend{name: "first", err: util.HaltRetries(ErrNoMatches)},
+			&stubBackend{name: "second", err: errDown},
+		}.QueryVideoID(t.Context(), Query{Artist: "artist", Title: "title"})
+		require.ErrorIs(t, err, errDown)
+		assert.Contains(t, err.Error(), "second: down")
+		var haltErr util.HaltRetriesError
+		assert.NotErrorAs(t, err, &haltErr)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		_, err := Fallback{}.QueryVideoID(t.Context(), Query{Artist: "artist", Title: "title"})
+		require.ErrorIs(t, err, ErrNoBackends)
+	})
+}
+
+func TestNewBackends(t *testing.T) {
+	u, err := url.Parse("https://example.com")
+	require.NoError(t, err)
+
+	conf := config.New()
+	conf.VideoIDBackendSpecs = []config.VideoIDBackend{
+		{Type: config.BackendPiped, URL: u},
+		{Type: config.BackendYouTube},
+		{Type: config.BackendInvidious, URL: u},
+	}
+
+	assert.Equal(t, "piped:example.com,invidious:example.com", NewBackends(conf).Name())
+
+	conf.YouTubeAPIKey = "key"
+	assert.Equal(t, "piped:example.com,youtube,invidious:example.com", NewBackends(conf).Name())
+}
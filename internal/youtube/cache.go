@@ -0,0 +1,205 @@
+package youtube
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"gabe565.com/castsponsorskip/internal/util"
+)
+
+var ErrCachedNoMatch = errors.New("cached search found no matching video")
+
+// Cache is a persistent LRU cache of video ID searches.
+// Searches that found no matching video are cached for a shorter time.
+type Cache struct {
+	Backend Backend
+
+	mu          sync.Mutex
+	path        string
+	size        int
+	ttl         time.Duration
+	negativeTTL time.Duration
+	order       *list.List // Most recently used first
+	entries     map[string]*list.Element
+	now         func() time.Time
+}
+
+type cacheEntry struct {
+	Key     string    `json:"key"`
+	VideoID string    `json:"video_id,omitempty"`
+	Expires time.Time `json:"expires"`
+}
+
+type cacheFile struct {
+	Entries []cacheEntry `json:"entries"`
+}
+
+// NewCache wraps a backend with a cache that is saved to path.
+// If path is empty, the cache is only kept in memory.
+func NewCache(backend Backend, path string, size int, ttl, negativeTTL time.Duration) *Cache {
+	return &Cache{
+		Backend:     backend,
+		path:        path,
+		size:        size,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		order:       list.New(),
+		entries:     make(map[string]*list.Element, size),
+		now:         time.Now,
+	}
+}
+
+func (c *Cache) Name() string {
+	return c.Backend.Name()
+}
+
+func (c *Cache) QueryVideoID(ctx context.Context, q Query) (string, error) {
+	key := cacheKey(q.Artist, q.Title)
+	if entry, ok := c.get(key); ok {
+		config.Logger(config.SubsystemYouTube).Debug("Using cached video ID search.", "video_id", entry.VideoID)
+		if entry.VideoID == "" {
+			return "", util.HaltRetries(ErrCachedNoMatch)
+		}
+		return entry.VideoID, nil
+	}
+
+	id, err := c.Backend.QueryVideoID(ctx, q)
+	switch {
+	case err == nil:
+		c.set(key, id, c.ttl)
+	case isNoMatch(err) && c.negativeTTL > 0:
+		c.set(key, "", c.negativeTTL)
+	}
+	return id, err
+}
+
+// isNoMatch returns true if the search succeeded but found no matching video.
+// Errors joined by Fallback only count if every backend found no match.
+func isNoMatch(err error) bool {
+	var haltErr util.HaltRetriesError
+	return errors.As(err, &haltErr) && allNoMatch(haltErr.Err)
+}
+
+func allNoMatch(err error) bool {
+	var joined interface{ Unwrap() []error }
+	if errors.As(err, &joined) {
+		errs := joined.Unwrap()
+		for _, err := range errs {
+			if !allNoMatch(err) {
+				return false
+			}
+		}
+		return len(errs) != 0
+	}
+	return errors.Is(err, ErrNoVideos) || errors.Is(err, ErrNoMatches)
+}
+
+func cacheKey(artist, title string) string {
+	return strings.ToLower(strings.TrimSpace(artist)) + "\n" + strings.ToLower(strings.TrimSpace(title))
+}
+
+func (c *Cache) get(key string) (cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return cacheEntry{}, false
+	}
+
+	entry := elem.Value.(cacheEntry) //nolint:errcheck
+	if !c.now().Before(entry.Expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return cacheEntry{}, false
+	}
+
+	c.order.MoveToFront(elem)
+	return entry, true
+}
+
+func (c *Cache) set(key, id string, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.put(cacheEntry{Key: key, VideoID: id, Expires: c.now().Add(ttl)})
+	if err := c.save(); err != nil {
+		config.Logger(config.SubsystemYouTube).Warn("Failed to save video ID cache.", "error", err.Error())
+	}
+}
+
+// put adds an entry as the most recently used, evicting the least recently used entries if full.
+func (c *Cache) put(entry cacheEntry) {
+	if elem, ok := c.entries[entry.Key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[entry.Key] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(cacheEntry).Key) //nolint:errcheck
+	}
+}
+
+// Load reads the cache file. A missing file is not an error.
+func (c *Cache) Load() error {
+	if c.path == "" {
+		return nil
+	}
+
+	b, err := os.ReadFile(c.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	var f cacheFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return fmt.Errorf("failed to parse video ID cache %q: %w", c.path, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	// Entries are saved most recently used first
+	for i := len(f.Entries) - 1; i >= 0; i-- {
+		if entry := f.Entries[i]; now.Before(entry.Expires) {
+			c.put(entry)
+		}
+	}
+	return nil
+}
+
+// save writes the cache file atomically. The caller must hold c.mu.
+func (c *Cache) save() error {
+	if c.path == "" {
+		return nil
+	}
+
+	f := cacheFile{Entries: make([]cacheEntry, 0, c.order.Len())}
+	for elem := c.order.Front(); elem != nil; elem = elem.Next() {
+		f.Entries = append(f.Entries, elem.Value.(cacheEntry)) //nolint:errcheck
+	}
+
+	b, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+
+	return util.WriteFileAtomic(c.path, b)
+}
@@ -0,0 +1,138 @@
+package youtube
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gabe565.com/castsponsorskip/internal/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCache(t *testing.T, backend Backend, size int) (*Cache, *time.Time) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewCache(backend, filepath.Join(t.TempDir(), "video-ids.json"), size, time.Hour, time.Minute)
+	cache.now = func() time.Time { return now }
+	return cache, &now
+}
+
+func TestCache_QueryVideoID(t *testing.T) {
+	backend := &stubBackend{name: "stub", id: "dQw4w9WgXcQ"}
+	cache, now := newTestCache(t, backend, 10)
+
+	for range 2 {
+		got, err := cache.QueryVideoID(t.Context(), Query{Artist: "Rick Astley", Title: "Never Gonna Give You Up"})
+		require.NoError(t, err)
+		assert.Equal(t, "dQw4w9WgXcQ", got)
+	}
+	assert.Equal(t, 1, backend.calls)
+
+	_, err := cache.QueryVideoID(t.Context(), Query{Artist: " rick astley", Title: "NEVER GONNA GIVE YOU UP "})
+	require.NoError(t, err)
+	assert.Equal(t, 1, backend.calls, "keys should ignore case and surrounding space")
+
+	*now = now.Add(time.Hour)
+	_, err = cache.QueryVideoID(t.Context(), Query{Artist: "Rick Astley", Title: "Never Gonna Give You Up"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, backend.calls, "expired entries should be searched again")
+}
+
+func TestCache_Negative(t *testing.T) {
+	backend := &stubBackend{name: "stub", err: util.HaltRetries(ErrNoMatches)}
+	cache, now := newTestCache(t, backend, 10)
+
+	_, err := cache.QueryVideoID(t.Context(), Query{Artist: "gabe565", Title: "Nonexistent video"})
+	require.ErrorIs(t, err, ErrNoMatches)
+
+	_, err = cache.QueryVideoID(t.Context(), Query{Artist: "gabe565", Title: "Nonexistent video"})
+	require.ErrorIs(t, err, ErrCachedNoMatch)
+	var haltErr util.HaltRetriesError
+	require.ErrorAs(t, err, &haltErr)
+	assert.Equal(t, 1, backend.calls)
+
+	*now = now.Add(time.Minute)
+	_, err = cache.QueryVideoID(t.Context(), Query{Artist: "gabe565", Title: "Nonexistent video"})
+	require.ErrorIs(t, err, ErrNoMatches)
+	assert.Equal(t, 2, backend.calls)
+
+	backend.err = errors.New("down")
+	_, err = cache.QueryVideoID(t.Context(), Query{Artist: "gabe565", Title: "Other video"})
+	require.Error(t, err)
+	_, err = cache.QueryVideoID(t.Context(), Query{Artist: "gabe565", Title: "Other video"})
+	require.Error(t, err)
+	assert.Equal(t, 4, backend.calls, "failed searches should not be cached")
+}
+
+func TestCache_NegativeFallback(t *testing.T) {
+	noMatch := &stubBackend{name: "youtube", err: util.HaltRetries(ErrNoMatches)}
+	noVideos := &stubBackend{name: "invidious", err: util.HaltRetries(ErrNoVideos)}
+	cache, _ := newTestCache(t, Fallback{noMatch, noVideos}, 10)
+
+	_, err := cache.QueryVideoID(t.Context(), Query{Artist: "gabe565", Title: "Nonexistent video"})
+	require.ErrorIs(t, err, ErrNoMatches)
+	_, err = cache.QueryVideoID(t.Context(), Query{Artist: "gabe565", Title: "Nonexistent video"})
+	require.ErrorIs(t, err, ErrCachedNoMatch)
+	assert.Equal(t, 1, noMatch.calls)
+
+	noVideos.err = util.HaltRetries(ErrNotConnected)
+	_, err = cache.QueryVideoID(t.Context(), Query{Artist: "gabe565", Title: "Other video"})
+	require.ErrorIs(t, err, ErrNoMatches)
+	_, err = cache.QueryVideoID(t.Context(), Query{Artist: "gabe565", Title: "Other video"})
+	require.ErrorIs(t, err, ErrNoMatches)
+	assert.Equal(t, 3, noMatch.calls, "searches should not be cached if any backend failed")
+}
+
+func TestCache_Evict(t *testing.T) {
+	backend := &stubBackend{name: "stub", id: "dQw4w9WgXcQ"}
+	cache, _ := newTestCache(t, backend, 2)
+
+	for _, title := range []string{"a", "b", "a", "c"} {
+		_, err := cache.QueryVideoID(t.Context(), Query{Artist: "artist", Title: title})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 3, backend.calls)
+
+	_, ok := cache.get(cacheKey("artist", "a"))
+	assert.True(t, ok)
+	_, ok = cache.get(cacheKey("artist", "b"))
+	assert.False(t, ok, "least recently used entry should be evicted")
+	_, ok = cache.get(cacheKey("artist", "c"))
+	assert.True(t, ok)
+}
+
+func TestCache_Load(t *testing.T) {
+	backend := &stubBackend{name: "stub", id: "dQw4w9WgXcQ"}
+	cache, now := newTestCache(t, backend, 2)
+
+	_, err := cache.QueryVideoID(t.Context(), Query{Artist: "artist", Title: "a"})
+	require.NoError(t, err)
+	*now = now.Add(30 * time.Minute)
+	_, err = cache.QueryVideoID(t.Context(), Query{Artist: "artist", Title: "b"})
+	require.NoError(t, err)
+
+	loaded := NewCache(backend, cache.path, 2, time.Hour, time.Minute)
+	loaded.now = func() time.Time { return now.Add(45 * time.Minute) }
+	require.NoError(t, loaded.Load())
+
+	_, ok := loaded.get(cacheKey("artist", "a"))
+	assert.False(t, ok, "expired entries should not be loaded")
+	entry, ok := loaded.get(cacheKey("artist", "b"))
+	require.True(t, ok)
+	assert.Equal(t, "dQw4w9WgXcQ", entry.VideoID)
+}
+
+func TestCache_LoadMissing(t *testing.T) {
+	cache := NewCache(&stubBackend{}, filepath.Join(t.TempDir(), "missing.json"), 2, time.Hour, time.Minute)
+	require.NoError(t, cache.Load())
+}
+
+func TestCache_LoadInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "video-ids.json")
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+
+	cache := NewCache(&stubBackend{}, path, 2, time.Hour, time.Minute)
+	require.Error(t, cache.Load())
+}
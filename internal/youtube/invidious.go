@@ -0,0 +1,77 @@
+package youtube
+
+import (
+	"context"
+	"net/url"
+	"path"
+	"time"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"gabe565.com/castsponsorskip/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Invidious searches an Invidious instance API.
+type Invidious struct {
+	URL *url.URL
+}
+
+func NewInvidious(u *url.URL) *Invidious {
+	return &Invidious{URL: u}
+}
+
+func (i *Invidious) Name() string {
+	return config.BackendInvidious + ":" + i.URL.Host
+}
+
+type invidiousResult struct {
+	Type          string `json:"type"`
+	Title         string `json:"title"`
+	VideoID       string `json:"videoId"`
+	Author        string `json:"author"`
+	LengthSeconds int    `json:"lengthSeconds"`
+}
+
+func (i *Invidious) QueryVideoID(ctx context.Context, q Query) (id string, err error) {
+	ctx, span := tracing.Start(ctx, "invidious.QueryVideoID",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("instance", i.URL.Host),
+			attribute.String("artist", q.Artist),
+			attribute.String("title", q.Title),
+		),
+	)
+	defer func() {
+		span.SetAttributes(attribute.String("video_id", id))
+		tracing.End(span, err)
+	}()
+
+	u := *i.URL
+	u.Path = path.Join("/", u.Path, "api", "v1", "search")
+	u.RawQuery = url.Values{
+		"q":    {q.Artist + " " + q.Title},
+		"type": {"video"},
+	}.Encode()
+
+	config.Logger(config.SubsystemYouTube).Debug("Searching for video ID", "backend", i.Name(), "url", u.String())
+	var results []invidiousResult
+	if err := getJSON(ctx, u.String(), &results); err != nil {
+		return "", err
+	}
+
+	candidates := make([]Candidate, 0, len(results))
+	for _, result := range results {
+		if result.Type != "" && result.Type != "video" {
+			continue
+		}
+		candidates = append(candidates, Candidate{
+			ID:       result.VideoID,
+			Title:    result.Title,
+			Channel:  result.Author,
+			Duration: time.Duration(result.LengthSeconds) * time.Second,
+		})
+	}
+
+	return bestMatch(q, candidates)
+}
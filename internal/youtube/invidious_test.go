@@ -0,0 +1,67 @@
+package youtube
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"gabe565.com/castsponsorskip/internal/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvidious_QueryVideoID(t *testing.T) {
+	tests := []struct {
+		name     string
+		artist   string
+		status   int
+		response string
+		want     string
+		wantErr  error
+		wantHalt bool
+	}{
+		{
+			"simple",
+			"Rick Astley",
+			http.StatusOK,
+			`[
+				{"type":"channel","author":"Rick Astley"},
+				{"type":"video","videoId":"aaaaaaaaaaa","title":"Never Gonna Give You Up (Cover)","author":"Someone Else"},
+				{"type":"video","videoId":"dQw4w9WgXcQ","title":"Never Gonna Give You Up","author":"Rick Astley"}
+			]`,
+			"dQw4w9WgXcQ",
+			nil,
+			false,
+		},
+		{"no videos", "Rick Astley", http.StatusOK, `[]`, "", ErrNoVideos, true},
+		{"no matches", "gabe565", http.StatusOK, `[{"type":"video","videoId":"dQw4w9WgXcQ","title":"Never Gonna Give You Up","author":"Rick Astley"}]`, "", ErrNoMatches, true},
+		{"error status", "Rick Astley", http.StatusInternalServerError, `{"error":"down"}`, "", ErrStatusCode, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/invidious/api/v1/search", r.URL.Path)
+				assert.Equal(t, tt.artist+" Never Gonna Give You Up", r.URL.Query().Get("q"))
+				assert.Equal(t, "video", r.URL.Query().Get("type"))
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			t.Cleanup(server.Close)
+
+			u, err := url.Parse(server.URL + "/invidious")
+			require.NoError(t, err)
+
+			got, err := NewInvidious(u).QueryVideoID(t.Context(), Query{Artist: tt.artist, Title: "Never Gonna Give You Up"})
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				var haltErr util.HaltRetriesError
+				assert.Equal(t, tt.wantHalt, errors.As(err, &haltErr))
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
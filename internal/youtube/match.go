@@ -0,0 +1,130 @@
+package youtube
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"gabe565.com/castsponsorskip/internal/util"
+)
+
+// MinMatchScore is the lowest score a search result needs to be used.
+const MinMatchScore = 0.8
+
+// Query describes the media playing on a device.
+type Query struct {
+	Artist string
+	Title  string
+	// Duration is zero if the device did not report it.
+	Duration time.Duration
+}
+
+// Candidate is a video returned by a search.
+type Candidate struct {
+	ID      string
+	Title   string
+	Channel string
+	// Duration is zero if the backend did not return it.
+	Duration time.Duration
+}
+
+// Score rates how likely a candidate is the queried video, from 0 to 1.
+// The title similarity is scaled down if the channel or duration do not match.
+// The channel and duration are only compared if both are known.
+func (q Query) Score(c Candidate) float64 {
+	score := titleSimilarity(q.Title, c.Title)
+
+	switch qChannel, cChannel := normalizeChannel(q.Artist), normalizeChannel(c.Channel); {
+	case qChannel == "", cChannel == "", qChannel == cChannel:
+	case strings.Contains(cChannel, qChannel), strings.Contains(qChannel, cChannel):
+		score *= 0.9
+	default:
+		score *= 0.5
+	}
+
+	if q.Duration > 0 && c.Duration > 0 {
+		const exact, mismatch = 2 * time.Second, 10 * time.Second
+		switch diff := (q.Duration - c.Duration).Abs(); {
+		case diff <= exact:
+		case diff >= mismatch:
+			score *= 0.5
+		default:
+			score *= 1 - 0.5*float64(diff-exact)/float64(mismatch-exact)
+		}
+	}
+
+	return score
+}
+
+// bestMatch returns the ID of the highest scoring candidate.
+// Earlier candidates win ties, since backends return results by relevance.
+func bestMatch(q Query, candidates []Candidate) (string, error) {
+	if len(candidates) == 0 {
+		return "", util.HaltRetries(ErrNoVideos)
+	}
+
+	var best Candidate
+	var bestScore float64
+	for _, c := range candidates {
+		if c.ID == "" {
+			continue
+		}
+		if score := q.Score(c); score > bestScore {
+			best, bestScore = c, score
+		}
+	}
+
+	if best.ID == "" {
+		return "", util.HaltRetries(ErrNoID)
+	}
+	if bestScore < MinMatchScore {
+		return "", util.HaltRetries(fmt.Errorf("%w: best result %q by %q scored %.2f",
+			ErrNoMatches, best.Title, best.Channel, bestScore,
+		))
+	}
+
+	config.Logger(config.SubsystemYouTube).Debug("Matched video.",
+		"video_id", best.ID, "title", best.Title, "channel", best.Channel, "score", bestScore,
+	)
+	return best.ID, nil
+}
+
+// titleSimilarity returns the Sørensen–Dice coefficient of the words in each title.
+func titleSimilarity(a, b string) float64 {
+	aWords, bWords := words(a), words(b)
+	if len(aWords) == 0 || len(bWords) == 0 {
+		return 0
+	}
+
+	counts := make(map[string]int, len(aWords))
+	for _, w := range aWords {
+		counts[w]++
+	}
+	var shared int
+	for _, w := range bWords {
+		if counts[w] > 0 {
+			counts[w]--
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(aWords)+len(bWords))
+}
+
+// words lowercases s and splits it on anything that is not a letter or digit.
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// normalizeChannel removes suffixes YouTube adds to auto-generated and label channels.
+// Spaces are removed, since label channels are often named like RickAstleyVEVO.
+func normalizeChannel(s string) string {
+	w := words(s)
+	if len(w) > 1 && w[len(w)-1] == "topic" {
+		w = w[:len(w)-1]
+	}
+	return strings.TrimSuffix(strings.Join(w, ""), "vevo")
+}
@@ -0,0 +1,149 @@
+package youtube
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gabe565.com/castsponsorskip/internal/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuery_Score(t *testing.T) {
+	query := Query{
+		Artist:   "Rick Astley",
+		Title:    "Rick Astley - Never Gonna Give You Up (Official Music Video)",
+		Duration: 213 * time.Second,
+	}
+	tests := []struct {
+		name      string
+		candidate Candidate
+		wantMin   float64
+		wantMax   float64
+	}{
+		{
+			"exact",
+			Candidate{Title: query.Title, Channel: "Rick Astley", Duration: 213 * time.Second},
+			1, 1,
+		},
+		{
+			"unknown duration",
+			Candidate{Title: query.Title, Channel: "Rick Astley"},
+			1, 1,
+		},
+		{
+			"topic channel",
+			Candidate{Title: query.Title, Channel: "Rick Astley - Topic", Duration: 214 * time.Second},
+			1, 1,
+		},
+		{
+			"vevo channel",
+			Candidate{Title: query.Title, Channel: "RickAstleyVEVO"},
+			1, 1,
+		},
+		{
+			"partial channel",
+			Candidate{Title: query.Title, Channel: "Rick Astley Official"},
+			0.9, 0.9,
+		},
+		{
+			"reupload",
+			Candidate{Title: query.Title, Channel: "Some Reupload Channel", Duration: 213 * time.Second},
+			0.5, 0.5,
+		},
+		{
+			"similar title on the same channel",
+			Candidate{Title: "Rick Astley - Never Gonna Give You Up (Live)", Channel: "Rick Astley", Duration: 213 * time.Second},
+			0, MinMatchScore,
+		},
+		{
+			"duration mismatch",
+			Candidate{Title: query.Title, Channel: "Rick Astley", Duration: 4 * time.Minute},
+			0.5, 0.5,
+		},
+		{
+			"close duration",
+			Candidate{Title: query.Title, Channel: "Rick Astley", Duration: 219 * time.Second},
+			0.5, 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := query.Score(tt.candidate)
+			assert.GreaterOrEqual(t, got, tt.wantMin)
+			assert.LessOrEqual(t, got, tt.wantMax)
+		})
+	}
+
+	t.Run("unknown artist", func(t *testing.T) {
+		query := Query{Title: query.Title}
+		original := query.Score(Candidate{Title: query.Title, Channel: "Rick Astley"})
+		reupload := query.Score(Candidate{Title: query.Title, Channel: "Some Reupload Channel"})
+		assert.InDelta(t, 1, original, 0)
+		assert.InDelta(t, original, reupload, 0, "the channel cannot be compared without an artist")
+	})
+
+	t.Run("unknown channel", func(t *testing.T) {
+		assert.InDelta(t, 1, query.Score(Candidate{Title: query.Title}), 0)
+	})
+}
+
+func Test_bestMatch(t *testing.T) {
+	query := Query{Artist: "Rick Astley", Title: "Never Gonna Give You Up", Duration: 213 * time.Second}
+	tests := []struct {
+		name       string
+		candidates []Candidate
+		want       string
+		wantErr    error
+	}{
+		{
+			"prefers original channel",
+			[]Candidate{
+				{ID: "aaaaaaaaaaa", Title: "Never Gonna Give You Up", Channel: "Reuploads"},
+				{ID: "dQw4w9WgXcQ", Title: "Never Gonna Give You Up", Channel: "Rick Astley"},
+			},
+			"dQw4w9WgXcQ",
+			nil,
+		},
+		{
+			"prefers matching duration",
+			[]Candidate{
+				{ID: "aaaaaaaaaaa", Title: "Never Gonna Give You Up", Channel: "Rick Astley", Duration: 10 * time.Minute},
+				{ID: "dQw4w9WgXcQ", Title: "Never Gonna Give You Up", Channel: "Rick Astley", Duration: 212 * time.Second},
+			},
+			"dQw4w9WgXcQ",
+			nil,
+		},
+		{
+			"earlier candidate wins ties",
+			[]Candidate{
+				{ID: "dQw4w9WgXcQ", Title: "Never Gonna Give You Up", Channel: "Rick Astley"},
+				{ID: "aaaaaaaaaaa", Title: "Never Gonna Give You Up", Channel: "Rick Astley"},
+			},
+			"dQw4w9WgXcQ",
+			nil,
+		},
+		{
+			"low confidence",
+			[]Candidate{{ID: "aaaaaaaaaaa", Title: "Never Gonna Give You Up", Channel: "Reuploads"}},
+			"",
+			ErrNoMatches,
+		},
+		{"no candidates", nil, "", ErrNoVideos},
+		{"missing ID", []Candidate{{Title: "Never Gonna Give You Up", Channel: "Rick Astley"}}, "", ErrNoID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := bestMatch(query, tt.candidates)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				var haltErr util.HaltRetriesError
+				assert.True(t, errors.As(err, &haltErr))
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
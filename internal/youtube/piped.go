@@ -0,0 +1,87 @@
+package youtube
+
+import (
+	"context"
+	"net/url"
+	"path"
+	"time"
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"gabe565.com/castsponsorskip/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Piped searches a Piped instance API.
+type Piped struct {
+	URL *url.URL
+}
+
+func NewPiped(u *url.URL) *Piped {
+	return &Piped{URL: u}
+}
+
+func (p *Piped) Name() string {
+	return config.BackendPiped + ":" + p.URL.Host
+}
+
+type pipedResponse struct {
+	Items []pipedItem `json:"items"`
+}
+
+type pipedItem struct {
+	URL          string `json:"url"`
+	Type         string `json:"type"`
+	Title        string `json:"title"`
+	UploaderName string `json:"uploaderName"`
+	Duration     int    `json:"duration"`
+}
+
+func (p *Piped) QueryVideoID(ctx context.Context, q Query) (id string, err error) {
+	ctx, span := tracing.Start(ctx, "piped.QueryVideoID",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("instance", p.URL.Host),
+			attribute.String("artist", q.Artist),
+			attribute.String("title", q.Title),
+		),
+	)
+	defer func() {
+		span.SetAttributes(attribute.String("video_id", id))
+		tracing.End(span, err)
+	}()
+
+	u := *p.URL
+	u.Path = path.Join("/", u.Path, "search")
+	u.RawQuery = url.Values{
+		"q":      {q.Artist + " " + q.Title},
+		"filter": {"videos"},
+	}.Encode()
+
+	config.Logger(config.SubsystemYouTube).Debug("Searching for video ID", "backend", p.Name(), "url", u.String())
+	var response pipedResponse
+	if err := getJSON(ctx, u.String(), &response); err != nil {
+		return "", err
+	}
+
+	candidates := make([]Candidate, 0, len(response.Items))
+	for _, item := range response.Items {
+		if item.Type != "" && item.Type != "stream" {
+			continue
+		}
+
+		// Piped returns relative watch URLs like /watch?v=dQw4w9WgXcQ
+		id, _ := ParseVideoID("youtube.com" + item.URL)
+		candidate := Candidate{
+			ID:      id,
+			Title:   item.Title,
+			Channel: item.UploaderName,
+		}
+		if item.Duration > 0 {
+			candidate.Duration = time.Duration(item.Duration) * time.Second
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	return bestMatch(q, candidates)
+}
@@ -0,0 +1,57 @@
+package youtube
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPiped_QueryVideoID(t *testing.T) {
+	tests := []struct {
+		name     string
+		artist   string
+		response string
+		want     string
+		wantErr  error
+	}{
+		{
+			"simple",
+			"Rick Astley",
+			`{"items":[
+				{"type":"channel","url":"/channel/UCuAXFkgsw1L7xaCfnd5JJOw","uploaderName":"Rick Astley"},
+				{"type":"stream","url":"/watch?v=dQw4w9WgXcQ","title":"Never Gonna Give You Up","uploaderName":"Rick Astley"}
+			]}`,
+			"dQw4w9WgXcQ",
+			nil,
+		},
+		{"no videos", "Rick Astley", `{"items":[]}`, "", ErrNoVideos},
+		{"no matches", "gabe565", `{"items":[{"type":"stream","url":"/watch?v=dQw4w9WgXcQ","title":"Never Gonna Give You Up","uploaderName":"Rick Astley"}]}`, "", ErrNoMatches},
+		{"missing ID", "Rick Astley", `{"items":[{"type":"stream","url":"","uploaderName":"Rick Astley"}]}`, "", ErrNoID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/search", r.URL.Path)
+				assert.Equal(t, tt.artist+" Never Gonna Give You Up", r.URL.Query().Get("q"))
+				assert.Equal(t, "videos", r.URL.Query().Get("filter"))
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			t.Cleanup(server.Close)
+
+			u, err := url.Parse(server.URL)
+			require.NoError(t, err)
+
+			got, err := NewPiped(u).QueryVideoID(t.Context(), Query{Artist: tt.artist, Title: "Never Gonna Give You Up"})
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package youtube
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+	_ "time/tzdata" // Quotas reset in Pacific time, which may be missing from minimal images
+
+	"gabe565.com/castsponsorskip/internal/config"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/youtube/v3"
+)
+
+var ErrQuotaExhausted = errors.New("YouTube API quota exhausted")
+
+const (
+	searchCost = 100
+	listCost   = 1
+)
+
+//nolint:gochecknoglobals
+var quotaLocation = func() *time.Location {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}()
+
+type apiKey struct {
+	index     int
+	service   *youtube.Service
+	used      int
+	exhausted bool
+}
+
+// keyPool tracks the estimated quota use of each API key.
+// Estimates are cleared at midnight Pacific time, when YouTube resets quotas.
+type keyPool struct {
+	mu      sync.Mutex
+	keys    []*apiKey
+	current int
+	limit   int
+	resetAt time.Time
+	now     func() time.Time
+}
+
+func newKeyPool(services []*youtube.Service, limit int) *keyPool {
+	p := &keyPool{
+		keys:  make([]*apiKey, 0, len(services)),
+		limit: limit,
+		now:   time.Now,
+	}
+	for i, service := range services {
+		p.keys = append(p.keys, &apiKey{index: i + 1, service: service})
+	}
+	p.resetAt = nextQuotaReset(p.now())
+	return p
+}
+
+func nextQuotaReset(t time.Time) time.Time {
+	y, m, d := t.In(quotaLocation).Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, quotaLocation)
+}
+
+// resetIfDue clears usage after the daily quota reset. The caller must hold p.mu.
+func (p *keyPool) resetIfDue() {
+	now := p.now()
+	if now.Before(p.resetAt) {
+		return
+	}
+	for _, k := range p.keys {
+		k.used, k.exhausted = 0, false
+	}
+	p.current = 0
+	p.resetAt = nextQuotaReset(now)
+}
+
+// acquire charges cost to the first key with enough quota left, starting at the current key.
+func (p *keyPool) acquire(cost int) (*apiKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.resetIfDue()
+
+	for range p.keys {
+		k := p.keys[p.current]
+		if !k.exhausted && k.used+cost <= p.limit {
+			k.used += cost
+			return k, nil
+		}
+		p.current = (p.current + 1) % len(p.keys)
+	}
+	return nil, fmt.Errorf("%w until %s", ErrQuotaExhausted, p.resetAt.Local().Format(time.DateTime))
+}
+
+// exhaust marks a key as out of quota after the API rejected it.
+func (p *keyPool) exhaust(k *apiKey) {
+	p.mu.Lock()
+	k.exhausted = true
+	k.used = p.limit
+	p.mu.Unlock()
+
+	status := p.status()
+	logger := config.Logger(config.SubsystemYouTube)
+	if status.AvailableKeys == 0 {
+		logger.Warn("All YouTube API keys are out of quota. Pausing searches until the quota resets.",
+			"key", k.index,
+			"reset_at", status.ResetAt.Local().Format(time.DateTime),
+		)
+		return
+	}
+	logger.Info("YouTube API key is out of quota. Rotating to the next key.",
+		"key", k.index,
+		"available_keys", status.AvailableKeys,
+		"remaining", status.Remaining,
+	)
+}
+
+// QuotaStatus is the estimated YouTube API quota left today.
+type QuotaStatus struct {
+	Remaining     int
+	Limit         int
+	Keys          int
+	AvailableKeys int
+	ResetAt       time.Time
+}
+
+func (p *keyPool) status() QuotaStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.resetIfDue()
+
+	status := QuotaStatus{
+		Limit:   p.limit * len(p.keys),
+		Keys:    len(p.keys),
+		ResetAt: p.resetAt,
+	}
+	for _, k := range p.keys {
+		if !k.exhausted {
+			status.Remaining += p.limit - k.used
+			if k.used < p.limit {
+				status.AvailableKeys++
+			}
+		}
+	}
+	return status
+}
+
+// Quota returns the estimated quota left, or false if no API key is configured.
+func Quota() (QuotaStatus, bool) {
+	if pool == nil {
+		return QuotaStatus{}, false
+	}
+	return pool.status(), true
+}
+
+// call runs fn with the first key that has quota left, rotating past keys that report quotaExceeded.
+func call(cost int, fn func(service *youtube.Service) error) error {
+	if pool == nil {
+		return ErrNotConnected
+	}
+
+	for {
+		k, err := pool.acquire(cost)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(k.service); !isQuotaExceeded(err) {
+			return err
+		}
+		pool.exhaust(k)
+	}
+}
+
+func isQuotaExceeded(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusForbidden {
+		return false
+	}
+	for _, item := range apiErr.Errors {
+		switch item.Reason {
+		case "quotaExceeded", "dailyLimitExceeded":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package youtube
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gabe565.com/castsponsorskip/internal/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	"google.golang.org/api/youtube/v3"
+)
+
+func TestNextQuotaReset(t *testing.T) {
+	tests := []struct {
+		name string
+		now  time.Time
+		want time.Time
+	}{
+		{
+			"before midnight Pacific",
+			time.Date(2025, 1, 15, 7, 59, 0, 0, time.UTC),
+			time.Date(2025, 1, 15, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			"after midnight Pacific",
+			time.Date(2025, 1, 15, 8, 0, 0, 0, time.UTC),
+			time.Date(2025, 1, 16, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			"daylight saving time",
+			time.Date(2025, 7, 15, 12, 0, 0, 0, time.UTC),
+			time.Date(2025, 7, 16, 7, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.True(t, tt.want.Equal(nextQuotaReset(tt.now)), nextQuotaReset(tt.now).UTC())
+		})
+	}
+}
+
+func TestKeyPool(t *testing.T) {
+	now := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	p := newKeyPool([]*youtube.Service{{}, {}}, 250)
+	p.now = func() time.Time { return now }
+	p.resetAt = nextQuotaReset(now)
+
+	var indexes []int
+	for range 4 {
+		k, err := p.acquire(searchCost)
+		require.NoError(t, err)
+		indexes = append(indexes, k.index)
+	}
+	assert.Equal(t, []int{1, 1, 2, 2}, indexes, "keys should rotate before their estimate exceeds the quota")
+
+	status := p.status()
+	assert.Equal(t, 100, status.Remaining)
+	assert.Equal(t, 500, status.Limit)
+	assert.Equal(t, 2, status.AvailableKeys)
+
+	_, err := p.acquire(searchCost)
+	require.ErrorIs(t, err, ErrQuotaExhausted, "no key has enough quota left for a search")
+
+	for _, want := range []int{2, 1} {
+		k, err := p.acquire(listCost)
+		require.NoError(t, err)
+		assert.Equal(t, want, k.index)
+		p.exhaust(k)
+	}
+	_, err = p.acquire(listCost)
+	require.ErrorIs(t, err, ErrQuotaExhausted)
+	assert.Zero(t, p.status().AvailableKeys)
+
+	now = p.resetAt
+	k, err := p.acquire(searchCost)
+	require.NoError(t, err)
+	assert.Equal(t, 1, k.index)
+	assert.Equal(t, 400, p.status().Remaining)
+}
+
+func TestQueryVideoID_RotateKeys(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Query().Get("key")
+		requests = append(requests, key)
+		if key == "exhausted" {
+			w.WriteHeader(http.StatusForbidden)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"error": googleapi.Error{
+					Code:    http.StatusForbidden,
+					Message: "quota exceeded",
+					Errors:  []googleapi.ErrorItem{{Reason: "quotaExceeded"}},
+				},
+			})
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(&youtube.SearchListResponse{
+			Items: []*youtube.SearchResult{{
+				Id:      &youtube.ResourceId{VideoId: "dQw4w9WgXcQ"},
+				Snippet: &youtube.SearchResultSnippet{ChannelTitle: "Rick Astley", Title: "Never Gonna Give You Up"},
+			}},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	require.NoError(t, CreateService(t.Context(), []string{"exhausted", "valid"}, 10000, option.WithEndpoint(server.URL)))
+	t.Cleanup(func() {
+		pool = nil
+	})
+
+	for range 2 {
+		got, err := QueryVideoID(t.Context(), Query{Artist: "Rick Astley", Title: "Never Gonna Give You Up"})
+		require.NoError(t, err)
+		assert.Equal(t, "dQw4w9WgXcQ", got)
+	}
+	assert.Equal(t, []string{"exhausted", "valid", "valid"}, requests)
+
+	quota, ok := Quota()
+	require.True(t, ok)
+	assert.Equal(t, 2, quota.Keys)
+	assert.Equal(t, 1, quota.AvailableKeys)
+	assert.Equal(t, 10000-2*searchCost, quota.Remaining)
+
+	pool.exhaust(pool.keys[1])
+	_, err := QueryVideoID(t.Context(), Query{Artist: "Rick Astley", Title: "Never Gonna Give You Up"})
+	require.ErrorIs(t, err, ErrQuotaExhausted)
+	var haltErr util.HaltRetriesError
+	require.ErrorAs(t, err, &haltErr)
+	assert.Len(t, requests, 3, "exhausted keys should not be used")
+}
@@ -0,0 +1,54 @@
+package youtube
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var ErrInvalidVideoID = errors.New("invalid video ID")
+
+//nolint:gochecknoglobals
+var videoIDRe = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+
+func ParseVideoID(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if videoIDRe.MatchString(s) {
+		return s, nil
+	}
+
+	if !strings.Contains(s, "://") {
+		s = "https://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidVideoID, err)
+	}
+
+	var id string
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	switch host {
+	case "youtu.be":
+		id = strings.Trim(u.Path, "/")
+	case "youtube.com", "m.youtube.com", "music.youtube.com", "youtube-nocookie.com":
+		if v := u.Query().Get("v"); v != "" {
+			id = v
+			break
+		}
+
+		parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+		if len(parts) == 2 {
+			switch parts[0] {
+			case "shorts", "embed", "live", "v":
+				id = parts[1]
+			}
+		}
+	}
+
+	if !videoIDRe.MatchString(id) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidVideoID, s)
+	}
+	return id, nil
+}
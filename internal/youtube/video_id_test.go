@@ -0,0 +1,37 @@
+package youtube
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVideoID(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr require.ErrorAssertionFunc
+	}{
+		{"dQw4w9WgXcQ", "dQw4w9WgXcQ", require.NoError},
+		{"https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=42s", "dQw4w9WgXcQ", require.NoError},
+		{"youtube.com/watch?v=dQw4w9WgXcQ", "dQw4w9WgXcQ", require.NoError},
+		{"https://m.youtube.com/watch?v=dQw4w9WgXcQ", "dQw4w9WgXcQ", require.NoError},
+		{"https://music.youtube.com/watch?v=dQw4w9WgXcQ&list=RD", "dQw4w9WgXcQ", require.NoError},
+		{"https://youtu.be/dQw4w9WgXcQ?si=abc", "dQw4w9WgXcQ", require.NoError},
+		{"https://www.youtube.com/shorts/dQw4w9WgXcQ", "dQw4w9WgXcQ", require.NoError},
+		{"https://www.youtube.com/embed/dQw4w9WgXcQ", "dQw4w9WgXcQ", require.NoError},
+		{"https://www.youtube.com/live/dQw4w9WgXcQ", "dQw4w9WgXcQ", require.NoError},
+		{"", "", require.Error},
+		{"dQw4w9WgXc", "", require.Error},
+		{"https://example.com/watch?v=dQw4w9WgXcQ", "", require.Error},
+		{"https://www.youtube.com/channel/UCuAXFkgsw1L7xaCfnd5JJOw", "", require.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseVideoID(tt.input)
+			tt.wantErr(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
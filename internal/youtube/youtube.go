@@ -4,10 +4,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log/slog"
-	"strings"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
 
+	"gabe565.com/castsponsorskip/internal/config"
+	"gabe565.com/castsponsorskip/internal/tracing"
 	"gabe565.com/castsponsorskip/internal/util"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/api/googleapi/transport"
 	"google.golang.org/api/option"
 	"google.golang.org/api/youtube/v3"
 )
@@ -17,54 +24,193 @@ var (
 	ErrNoVideos     = errors.New("search returned no videos")
 	ErrNoMatches    = errors.New("no search results matched video metadata")
 	ErrNoID         = errors.New("search result missing video ID")
+
+	ErrInvalidDuration = errors.New("invalid duration")
 )
 
 //nolint:gochecknoglobals
-var service *youtube.Service
-
-func CreateService(ctx context.Context, apiKey string, opts ...option.ClientOption) error {
-	var err error
-	opts = append(
-		opts,
-		option.WithAPIKey(apiKey),
-		option.WithTelemetryDisabled(),
-	)
-	service, err = youtube.NewService(ctx, opts...)
-	return err
+var pool *keyPool
+
+// CreateService creates a client for each API key.
+// quota is the daily quota of each key.
+func CreateService(ctx context.Context, apiKeys []string, quota int, opts ...option.ClientOption) error {
+	if len(apiKeys) == 0 {
+		pool = nil
+		return nil
+	}
+
+	services := make([]*youtube.Service, 0, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		service, err := youtube.NewService(ctx, append(
+			opts,
+			option.WithHTTPClient(&http.Client{
+				Transport: &transport.APIKey{Key: apiKey, Transport: tracing.Transport(nil)},
+			}),
+			option.WithTelemetryDisabled(),
+		)...)
+		if err != nil {
+			return err
+		}
+		services = append(services, service)
+	}
+	pool = newKeyPool(services, quota)
+	return nil
+}
+
+func Ping(ctx context.Context, videoID string) error {
+	return call(listCost, func(service *youtube.Service) error {
+		_, err := service.Videos.List([]string{"id"}).
+			Id(videoID).
+			Context(ctx).
+			Do()
+		return err
+	})
+}
+
+// DataAPI searches with the YouTube Data API.
+// CreateService must be called first.
+type DataAPI struct{}
+
+func (DataAPI) Name() string {
+	return config.BackendYouTube
 }
 
-func QueryVideoID(ctx context.Context, artist, title string) (string, error) {
-	if service == nil {
+func (DataAPI) QueryVideoID(ctx context.Context, q Query) (string, error) {
+	return QueryVideoID(ctx, q)
+}
+
+func QueryVideoID(ctx context.Context, q Query) (id string, err error) {
+	ctx, span := tracing.Start(ctx, "youtube.QueryVideoID",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("artist", q.Artist),
+			attribute.String("title", q.Title),
+		),
+	)
+	defer func() {
+		span.SetAttributes(attribute.String("video_id", id))
+		tracing.End(span, err)
+	}()
+
+	if pool == nil {
 		return "", util.HaltRetries(ErrNotConnected)
 	}
 
-	query := fmt.Sprintf(`%q+intitle:%q`, artist, title)
-	slog.Debug("Searching for video ID", "query", query)
-	response, err := service.Search.List([]string{"id", "snippet"}).
-		Q(query).
-		Context(ctx).
-		Do()
+	query := fmt.Sprintf(`%q+intitle:%q`, q.Artist, q.Title)
+	logger := config.Logger(config.SubsystemYouTube)
+	logger.Debug("Searching for video ID", "backend", config.BackendYouTube, "query", query)
+	var response *youtube.SearchListResponse
+	err = call(searchCost, func(service *youtube.Service) error {
+		var err error
+		response, err = service.Search.List([]string{"id", "snippet"}).
+			Type("video").
+			Q(query).
+			Context(ctx).
+			Do()
+		return err
+	})
 	if err != nil {
+		logQuota(span)
+		if errors.Is(err, ErrQuotaExhausted) {
+			return "", util.HaltRetries(err)
+		}
 		return "", err
 	}
 
-	if len(response.Items) == 0 {
-		return "", util.HaltRetries(ErrNoVideos)
-	}
-
+	candidates := make([]Candidate, 0, len(response.Items))
 	for _, item := range response.Items {
 		if item == nil || item.Snippet == nil {
 			continue
 		}
-		if !strings.Contains(strings.ToLower(item.Snippet.ChannelTitle), strings.ToLower(artist)) {
+		candidate := Candidate{
+			Title:   item.Snippet.Title,
+			Channel: item.Snippet.ChannelTitle,
+		}
+		if item.Id != nil {
+			candidate.ID = item.Id.VideoId
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	if q.Duration > 0 && len(candidates) != 0 {
+		if err := fillDurations(ctx, candidates); err != nil {
+			logger.Debug("Failed to fetch video durations. Matching without them.", "error", err.Error())
+		}
+	}
+	logQuota(span)
+
+	return bestMatch(q, candidates)
+}
+
+// fillDurations sets the duration of each candidate with videos.list.
+func fillDurations(ctx context.Context, candidates []Candidate) error {
+	ids := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		if c.ID != "" {
+			ids = append(ids, c.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var response *youtube.VideoListResponse
+	if err := call(listCost, func(service *youtube.Service) error {
+		var err error
+		response, err = service.Videos.List([]string{"contentDetails"}).
+			Id(ids...).
+			Context(ctx).
+			Do()
+		return err
+	}); err != nil {
+		return err
+	}
+
+	durations := make(map[string]time.Duration, len(response.Items))
+	for _, item := range response.Items {
+		if item == nil || item.ContentDetails == nil {
 			continue
 		}
-		if item.Id == nil || item.Id.VideoId == "" {
-			return "", util.HaltRetries(ErrNoID)
+		if d, err := parseISODuration(item.ContentDetails.Duration); err == nil {
+			durations[item.Id] = d
 		}
+	}
+	for i, c := range candidates {
+		candidates[i].Duration = durations[c.ID]
+	}
+	return nil
+}
 
-		return item.Id.VideoId, nil
+func logQuota(span trace.Span) {
+	if quota, ok := Quota(); ok {
+		span.SetAttributes(attribute.Int("quota.remaining", quota.Remaining))
+		config.Logger(config.SubsystemYouTube).Info("Estimated YouTube API quota remaining.",
+			"remaining", quota.Remaining,
+			"limit", quota.Limit,
+		)
 	}
+}
 
-	return "", util.HaltRetries(ErrNoMatches)
+//nolint:gochecknoglobals
+var isoDurationRe = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseISODuration parses the ISO 8601 durations returned by the Data API, like PT3M33S.
+func parseISODuration(s string) (time.Duration, error) {
+	match := isoDurationRe.FindStringSubmatch(s)
+	if match == nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidDuration, s)
+	}
+
+	var d time.Duration
+	for i, unit := range []time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		if match[i+1] == "" {
+			continue
+		}
+		v, err := strconv.Atoi(match[i+1])
+		if err != nil {
+			return 0, fmt.Errorf("%w: %q: %w", ErrInvalidDuration, s, err)
+		}
+		d += time.Duration(v) * unit
+	}
+	return d, nil
 }
@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"gabe565.com/castsponsorskip/cmd"
+	"gabe565.com/castsponsorskip/internal/config"
 	"gabe565.com/utils/cobrax"
 )
 
@@ -12,8 +13,12 @@ var version = "beta"
 
 func main() {
 	rootCmd := cmd.New(cobrax.WithVersion(version))
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	if err != nil {
 		slog.Error(err.Error())
+	}
+	_ = config.CloseLogSinks()
+	if err != nil {
 		os.Exit(1)
 	}
 }